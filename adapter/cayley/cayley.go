@@ -4,33 +4,34 @@ import (
 	"github.com/cayleygraph/cayley/graph/path"
 	"context"
 	"github.com/cayleygraph/cayley/quad"
-	"hash/fnv"
 	"github.com/cayleygraph/cayley"
 	"gonum.org/v1/gonum/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"gonum.org/v1/gonum/graph/simple"
 )
 
+// Value is a graph node with the ID its value was interned with.
 type Value struct {
 	quad.Value
+	id int64
 }
 
 func (node Value) ID() int64 {
-	s := fnv.New32a()
-	s.Write([]byte(node.Value.String()))
-	return int64(s.Sum32())
+	return node.id
 }
 
 type Iterator struct {
 	it  cayley.Iterator
 	qs  cayley.QuadStore
+	ids *Interner
 	ctx context.Context
 }
 
-func NewIterator(ctx context.Context, it cayley.Iterator, qs cayley.QuadStore) *Iterator {
+func NewIterator(ctx context.Context, it cayley.Iterator, qs cayley.QuadStore, ids *Interner) *Iterator {
 	return &Iterator{
 		it:  it.Clone(),
 		qs:  qs,
+		ids: ids,
 		ctx: ctx,
 	}
 }
@@ -53,45 +54,83 @@ func (it *Iterator) Reset() {
 
 func (it *Iterator) Node() graph.Node {
 	value := it.qs.NameOf(it.it.Result())
-	return Value{value}
+	return Value{Value: value, id: it.ids.ID(value)}
 }
 
+// Directed is a directed graph of nodes of a quadstore, with an edge from each
+// node to the nodes adj follows to. Node IDs are interned as nodes are seen,
+// so they are only stable for the life of the Directed.
 type Directed struct {
 	qs    cayley.QuadStore
 	adj   *path.Path
+	nodes *path.Path
+	ids   *Interner
 	ctx   context.Context
-	nodes map[int64]graph.Node
 }
 
+// NewDirected returns the graph of the nodes with an edge to or from them.
 func NewDirected(ctx context.Context, qs cayley.QuadStore, adj *path.Path) *Directed {
+	return NewDirectedOf(ctx, qs, adj, nil)
+}
+
+// NewDirectedOf returns the graph of the nodes the morphism nodes follows to
+// from every node of the quadstore, e.g. those with an fs:dir. Edges to other
+// nodes are left out. If nodes is nil, it is the graph of NewDirected.
+func NewDirectedOf(ctx context.Context, qs cayley.QuadStore, adj, nodes *path.Path) *Directed {
 	return &Directed{
 		ctx:   ctx,
 		qs:    qs,
 		adj:   adj,
-		nodes: make(map[int64]graph.Node),
+		nodes: nodes,
+		ids:   NewInterner(),
 	}
 }
 
-func (g *Directed) Node(id int64) graph.Node {
-	node, ok := g.nodes[id]
-	if ok {
-		return node
+// set returns the nodes of g.
+func (g *Directed) set() *path.Path {
+	p := path.StartPath(g.qs)
+	if g.nodes != nil {
+		return p.Follow(g.nodes)
+	}
+	return p.Follow(g.adj).Or(p.Follow(g.adj.Reverse()))
+}
+
+// follow follows adj from p to the nodes of g.
+func (g *Directed) follow(p, adj *path.Path) *path.Path {
+	p = p.Follow(adj)
+	if g.nodes != nil {
+		p = p.And(g.set())
+	}
+	return p
+}
+
+// NodeOf returns the node of value v, or nil if v is not a node of g.
+func (g *Directed) NodeOf(v quad.Value) graph.Node {
+	if g.qs.ValueOf(v) == nil {
+		return nil
 	}
-	nodes := g.Nodes()
-	for nodes.Next() {
-		n := nodes.Node()
-		nid := n.ID()
-		g.nodes[nid] = n
-		if nid == id {
-			return n
-		}
+	if found, err := g.set().Is(v).Iterate(g.ctx).FirstValue(nil); err != nil || found == nil {
+		return nil
+	}
+	return g.node(v)
+}
+
+// node returns the node of v, which must be a node of g.
+func (g *Directed) node(v quad.Value) Value {
+	return Value{Value: v, id: g.ids.ID(v)}
+}
+
+func (g *Directed) Node(id int64) graph.Node {
+	v := g.ids.Value(id)
+	if v == nil {
+		return nil
 	}
-	return nil
+	return Value{Value: v, id: id}
 }
 
 func (g *Directed) Nodes() graph.Nodes {
-	it := path.StartPath(g.qs).BuildIterator()
-	return NewIterator(g.ctx, it, g.qs)
+	it := g.set().Unique().BuildIterator()
+	return NewIterator(g.ctx, it, g.qs, g.ids)
 }
 
 func (g *Directed) From(id int64) graph.Nodes {
@@ -99,8 +138,8 @@ func (g *Directed) From(id int64) graph.Nodes {
 	if u == nil {
 		return nil
 	}
-	it := path.StartPath(g.qs, u.(Value)).Follow(g.adj).BuildIterator()
-	return NewIterator(g.ctx, it, g.qs)
+	it := g.follow(path.StartPath(g.qs, u.(Value).Value), g.adj).Unique().BuildIterator()
+	return NewIterator(g.ctx, it, g.qs, g.ids)
 }
 
 func (g *Directed) To(id int64) graph.Nodes {
@@ -108,8 +147,8 @@ func (g *Directed) To(id int64) graph.Nodes {
 	if v == nil {
 		return nil
 	}
-	it := path.StartPath(g.qs, v.(Value)).Follow(g.adj.Reverse()).BuildIterator()
-	return NewIterator(g.ctx, it, g.qs)
+	it := g.follow(path.StartPath(g.qs, v.(Value).Value), g.adj.Reverse()).Unique().BuildIterator()
+	return NewIterator(g.ctx, it, g.qs, g.ids)
 }
 
 func (g *Directed) HasEdgeFromTo(uid, vid int64) bool {
@@ -121,7 +160,7 @@ func (g *Directed) HasEdgeFromTo(uid, vid int64) bool {
 	if v == nil {
 		return false
 	}
-	nodes, err := path.StartPath(g.qs, u.(Value)).Follow(g.adj).Is(v.(Value)).Iterate(g.ctx).AllValues(nil)
+	nodes, err := g.follow(path.StartPath(g.qs, u.(Value).Value), g.adj).Is(v.(Value).Value).Iterate(g.ctx).AllValues(nil)
 	if err != nil {
 		// TODO: Warning?
 		return false
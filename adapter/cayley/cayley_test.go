@@ -9,20 +9,29 @@ import (
 	"gonum.org/v1/gonum/graph"
 	"log"
 	"gonum.org/v1/gonum/graph/topo"
+	"reflect"
 )
 
-func newValue(k interface{}) (v Value) {
+func newValue(k interface{}) (v quad.Value) {
 	switch t := k.(type) {
 	case string:
-		v = Value{quad.String(t)}
+		v = quad.String(t)
 	case quad.Value:
-		v = Value{t}
+		v = t
 	default:
 		log.Panicf("not supported for test value: %v", k)
 	}
 	return v
 }
 
+// nodeID returns the ID of the node of k in g, or -1 if it is not in g.
+func nodeID(g *Directed, k interface{}) int64 {
+	if n := g.NodeOf(newValue(k)); n != nil {
+		return n.ID()
+	}
+	return -1
+}
+
 func listEqual(a, b []graph.Node) bool {
 	if len(a) != len(b) {
 		return false
@@ -111,7 +120,7 @@ func TestIterator(t *testing.T) {
 		}
 	}
 	a := make(map[int64]graph.Node)
-	it := NewIterator(ctx, path.StartPath(qs).BuildIterator(), qs)
+	it := NewIterator(ctx, path.StartPath(qs).BuildIterator(), qs, NewInterner())
 	for it.Next() {
 		n := it.Node()
 		a[n.ID()] = n
@@ -131,13 +140,20 @@ func TestDirected_Node(t *testing.T) {
 	if err := qs.AddQuad(q); err != nil {
 		t.Skipf("error creating edge: %v", err)
 	}
-	e := Value{quad.String("1")}
-	eid := e.ID()
 	g := NewDirected(ctx, qs, path.StartMorphism().Out())
-	a := g.Node(eid)
-	aid := a.ID()
-	if aid != eid {
-		t.Fatalf("unexpected node: expected %v, got %v", eid, aid)
+	e := g.NodeOf(quad.String("1"))
+	if e == nil {
+		t.Fatalf("node not found")
+	}
+	a := g.Node(e.ID())
+	if a == nil || a.(Value).Value != quad.String("1") {
+		t.Fatalf("unexpected node: expected %v, got %v", e, a)
+	}
+	if g.NodeOf(quad.String("3")) != nil {
+		t.Fatalf("unexpected node for missing value")
+	}
+	if g.Node(e.ID()+2) != nil {
+		t.Fatalf("unexpected node for unknown id")
 	}
 }
 
@@ -152,10 +168,8 @@ func TestDirected_From(t *testing.T) {
 		t.Skipf("error creating edge: %v", err)
 	}
 	g := NewDirected(ctx, qs, path.StartMorphism().Out())
-	u := Value{quad.String("1")}
-	uid := u.ID()
-	v := Value{quad.String("2")}
-	vid := v.ID()
+	uid := g.NodeOf(quad.String("1")).ID()
+	vid := g.NodeOf(quad.String("2")).ID()
 	a := g.From(uid)
 	c := a.Len()
 	if c != 1 {
@@ -179,10 +193,8 @@ func TestDirected_To(t *testing.T) {
 		t.Skipf("error creating edge: %v", err)
 	}
 	g := NewDirected(ctx, qs, path.StartMorphism().Out())
-	u := Value{quad.String("1")}
-	uid := u.ID()
-	v := Value{quad.String("2")}
-	vid := v.ID()
+	uid := g.NodeOf(quad.String("1")).ID()
+	vid := g.NodeOf(quad.String("2")).ID()
 	a := g.To(vid)
 	c := a.Len()
 	if c != 1 {
@@ -239,13 +251,13 @@ func TestDirected_HasEdgeFromTo(t *testing.T) {
 				t.Skipf("error creating graph: %v", err)
 			}
 			for _, e := range test.edges {
-				q := quad.Make(newValue(e.from).Value, nil, newValue(e.to).Value, nil)
+				q := quad.Make(newValue(e.from), nil, newValue(e.to), nil)
 				if err := qs.AddQuad(q); err != nil {
 					t.Skipf("error creating edge: %v", err)
 				}
 			}
 			g := NewDirected(ctx, qs, path.StartMorphism().Out())
-			h := g.HasEdgeFromTo(newValue(test.from).ID(), newValue(test.to).ID())
+			h := g.HasEdgeFromTo(nodeID(g, test.from), nodeID(g, test.to))
 			if h != test.has {
 				t.Fatalf("unexpected result: expected %v, got %v", test.has, h)
 			}
@@ -309,13 +321,13 @@ func TestDirected_HasEdgeBetween(t *testing.T) {
 				t.Skipf("error creating graph: %v", err)
 			}
 			for _, e := range test.edges {
-				q := quad.Make(newValue(e.from).Value, nil, newValue(e.to).Value, nil)
+				q := quad.Make(newValue(e.from), nil, newValue(e.to), nil)
 				if err := qs.AddQuad(q); err != nil {
 					t.Skipf("error creating edge: %v", err)
 				}
 			}
 			g := NewDirected(ctx, qs, path.StartMorphism().Out())
-			h := g.HasEdgeBetween(newValue(test.x).ID(), newValue(test.y).ID())
+			h := g.HasEdgeBetween(nodeID(g, test.x), nodeID(g, test.y))
 			if h != test.has {
 				t.Fatalf("unexpected result: expected %v, got %v", test.has, h)
 			}
@@ -412,20 +424,20 @@ func TestDirectedCyclesIn(t *testing.T) {
 				t.Skipf("error creating graph: %v", err)
 			}
 			for _, e := range test.edges {
-				q := quad.Make(newValue(e.from).Value, nil, newValue(e.to).Value, nil)
+				q := quad.Make(newValue(e.from), nil, newValue(e.to), nil)
 				if err := qs.AddQuad(q); err != nil {
 					t.Skipf("error creating edge: %v", err)
 				}
 			}
+			g := NewDirected(ctx, qs, path.StartMorphism().Out())
 			e := make([][]graph.Node, len(test.cycles))
 			for i, c := range test.cycles {
 				e[i] = make([]graph.Node, len(c))
 				for j, v := range c {
-					e[i][j] = newValue(v)
+					e[i][j] = g.NodeOf(newValue(v))
 				}
 			}
 			// Test
-			g := NewDirected(ctx, qs, path.StartMorphism().Out())
 			a := topo.DirectedCyclesIn(g)
 			if !cycleSetEqual(e, a) {
 				t.Fatalf("unexpected cycles: expected %v, got %v", e, a)
@@ -434,3 +446,79 @@ func TestDirectedCyclesIn(t *testing.T) {
 	}
 }
 
+func TestDirected_Nodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes *path.Path
+		all   []string
+		from  []string
+	}{
+		{
+			"adjacency",
+			nil,
+			[]string{"a", "b", "x"},
+			[]string{"b", "x"},
+		},
+		{
+			"morphism",
+			path.StartMorphism().Has(quad.IRI("type"), quad.IRI("file")),
+			[]string{"a", "b", "c"},
+			[]string{"b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			qs, err := cayley.NewMemoryGraph()
+			if err != nil {
+				t.Skipf("error creating graph: %v", err)
+			}
+			for _, q := range []quad.Quad{
+				quad.Make(quad.IRI("a"), quad.IRI("inc"), quad.IRI("b"), nil),
+				quad.Make(quad.IRI("a"), quad.IRI("inc"), quad.IRI("x"), nil),
+				quad.Make(quad.IRI("a"), quad.IRI("name"), quad.String("a.h"), nil),
+				quad.Make(quad.IRI("a"), quad.IRI("type"), quad.IRI("file"), nil),
+				quad.Make(quad.IRI("b"), quad.IRI("type"), quad.IRI("file"), nil),
+				quad.Make(quad.IRI("c"), quad.IRI("type"), quad.IRI("file"), nil),
+			} {
+				if err := qs.AddQuad(q); err != nil {
+					t.Skipf("error creating quad: %v", err)
+				}
+			}
+			g := NewDirectedOf(ctx, qs, path.StartMorphism().Out(quad.IRI("inc")), test.nodes)
+			ids := func(names []string) map[int64]bool {
+				ids := make(map[int64]bool)
+				for _, name := range names {
+					n := g.NodeOf(quad.IRI(name))
+					if n == nil {
+						t.Fatalf("node %v not found", name)
+					}
+					ids[n.ID()] = true
+				}
+				return ids
+			}
+			// Test
+			if e, a := ids(test.all), idSet(g.Nodes()); !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected nodes: expected %v, got %v", e, a)
+			}
+			if n := g.NodeOf(quad.String("a.h")); n != nil {
+				t.Fatalf("unexpected node: %v", n)
+			}
+			aid := g.NodeOf(quad.IRI("a")).ID()
+			if e, a := ids(test.from), idSet(g.From(aid)); !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected from: expected %v, got %v", e, a)
+			}
+			s, err := g.Snapshot()
+			if err != nil {
+				t.Fatalf("error taking snapshot: %v", err)
+			}
+			if e, a := ids(test.all), idSet(s.Nodes()); !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected snapshot nodes: expected %v, got %v", e, a)
+			}
+			if e, a := ids(test.from), idSet(s.From(aid)); !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected snapshot from: expected %v, got %v", e, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package cayley
+
+import (
+	"github.com/cayleygraph/cayley/quad"
+)
+
+// Interner assigns quad values dense IDs in the order they are first seen,
+// so that distinct values never share an ID. IDs are only meaningful to the
+// Interner that assigned them.
+type Interner struct {
+	ids    map[string]int64
+	values []quad.Value
+}
+
+func NewInterner() *Interner {
+	return &Interner{ids: make(map[string]int64)}
+}
+
+// ID returns the ID of v, assigning the next free ID if v has none.
+func (in *Interner) ID(v quad.Value) int64 {
+	key := quad.StringOf(v)
+	if id, ok := in.ids[key]; ok {
+		return id
+	}
+	id := int64(len(in.values))
+	in.ids[key] = id
+	in.values = append(in.values, v)
+	return id
+}
+
+// Lookup returns the ID of v, if it has one.
+func (in *Interner) Lookup(v quad.Value) (int64, bool) {
+	id, ok := in.ids[quad.StringOf(v)]
+	return id, ok
+}
+
+// Value returns the value with id, or nil if no value has it.
+func (in *Interner) Value(id int64) quad.Value {
+	if id < 0 || id >= int64(len(in.values)) {
+		return nil
+	}
+	return in.values[id]
+}
+
+// Len returns the number of IDs assigned.
+func (in *Interner) Len() int {
+	return len(in.values)
+}
@@ -0,0 +1,43 @@
+package cayley
+
+import (
+	"testing"
+	"github.com/cayleygraph/cayley/quad"
+	"fmt"
+)
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+	// Enough values for 32-bit hashes of them to be likely to collide
+	const n = 200000
+	for i := 0; i < n; i++ {
+		v := quad.IRI(fmt.Sprintf("fs:%v", i))
+		if id := in.ID(v); id != int64(i) {
+			t.Fatalf("unexpected id for %v: expected %v, got %v", v, i, id)
+		}
+	}
+	if in.Len() != n {
+		t.Fatalf("unexpected len: expected %v, got %v", n, in.Len())
+	}
+	for _, i := range []int{0, 1, n / 2, n - 1} {
+		v := quad.IRI(fmt.Sprintf("fs:%v", i))
+		if id, ok := in.Lookup(v); !ok || id != int64(i) {
+			t.Fatalf("unexpected lookup for %v: expected %v, got %v (%v)", v, i, id, ok)
+		}
+		if a := in.Value(int64(i)); a != v {
+			t.Fatalf("unexpected value for %v: expected %v, got %v", i, v, a)
+		}
+	}
+	// Values of different types with the same text are distinct
+	if in.ID(quad.String("fs:0")) == in.ID(quad.IRI("fs:0")) {
+		t.Fatalf("string and IRI share an id")
+	}
+	if _, ok := in.Lookup(quad.IRI("fs:missing")); ok {
+		t.Fatalf("unexpected lookup of missing value")
+	}
+	for _, id := range []int64{-1, int64(in.Len())} {
+		if v := in.Value(id); v != nil {
+			t.Fatalf("unexpected value for %v: %v", id, v)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package cayley
+
+import (
+	"context"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/iterator"
+)
+
+// Line is a line of a Multigraph, for one quad from F to T.
+type Line struct {
+	F, T graph.Node
+	Quad quad.Quad
+	id   int64
+}
+
+func (l Line) From() graph.Node {
+	return l.F
+}
+
+func (l Line) To() graph.Node {
+	return l.T
+}
+
+func (l Line) ReversedLine() graph.Line {
+	l.F, l.T = l.T, l.F
+	return l
+}
+
+func (l Line) ID() int64 {
+	return l.id
+}
+
+// Multigraph is a directed multigraph of nodes of a quadstore, with a line for
+// each quad with predicate pred. Quads that differ only by label, such as
+// include edges resolved from different directives, are parallel lines.
+type Multigraph struct {
+	g     *Directed
+	pred  quad.IRI
+	lines map[string]int64
+}
+
+// NewMultigraph returns the multigraph of the nodes with a line to or from
+// them, or of the nodes the morphism nodes follows to as for NewDirectedOf.
+func NewMultigraph(ctx context.Context, qs cayley.QuadStore, pred quad.IRI, nodes *path.Path) *Multigraph {
+	return &Multigraph{
+		g:     NewDirectedOf(ctx, qs, path.StartMorphism().Out(pred), nodes),
+		pred:  pred,
+		lines: make(map[string]int64),
+	}
+}
+
+func (m *Multigraph) NodeOf(v quad.Value) graph.Node {
+	return m.g.NodeOf(v)
+}
+
+func (m *Multigraph) Node(id int64) graph.Node {
+	return m.g.Node(id)
+}
+
+func (m *Multigraph) Nodes() graph.Nodes {
+	return m.g.Nodes()
+}
+
+func (m *Multigraph) From(id int64) graph.Nodes {
+	if nodes := m.g.From(id); nodes != nil {
+		return nodes
+	}
+	return graph.Empty
+}
+
+func (m *Multigraph) To(id int64) graph.Nodes {
+	if nodes := m.g.To(id); nodes != nil {
+		return nodes
+	}
+	return graph.Empty
+}
+
+func (m *Multigraph) HasEdgeFromTo(uid, vid int64) bool {
+	return m.g.HasEdgeFromTo(uid, vid)
+}
+
+func (m *Multigraph) HasEdgeBetween(xid, yid int64) bool {
+	return m.g.HasEdgeBetween(xid, yid)
+}
+
+// Lines returns a line for each quad from u to v. Line IDs are interned by
+// quad, like node IDs.
+func (m *Multigraph) Lines(uid, vid int64) graph.Lines {
+	u, v := m.g.Node(uid), m.g.Node(vid)
+	if u == nil || v == nil {
+		return graph.Empty
+	}
+	qs := m.g.qs
+	it := qs.QuadIterator(quad.Subject, qs.ValueOf(u.(Value).Value))
+	defer it.Close()
+	var lines []graph.Line
+	for it.Next(m.g.ctx) {
+		q := qs.Quad(it.Result())
+		if q.Predicate != m.pred || q.Object != v.(Value).Value {
+			continue
+		}
+		key := q.NQuad()
+		id, ok := m.lines[key]
+		if !ok {
+			id = int64(len(m.lines))
+			m.lines[key] = id
+		}
+		lines = append(lines, Line{F: u, T: v, Quad: q, id: id})
+	}
+	if len(lines) == 0 {
+		return graph.Empty
+	}
+	return iterator.NewOrderedLines(lines)
+}
@@ -0,0 +1,130 @@
+package cayley
+
+import (
+	"testing"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"context"
+	"gonum.org/v1/gonum/graph"
+)
+
+func TestMultigraph_Lines(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		lines int
+	}{
+		{"parallel", "a", "b", 2},
+		{"single", "a", "c", 1},
+		{"reverse", "b", "a", 0},
+		{"other predicate", "c", "a", 0},
+		{"missing", "a", "x", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			qs, err := cayley.NewMemoryGraph()
+			if err != nil {
+				t.Skipf("error creating graph: %v", err)
+			}
+			inc := quad.IRI("inc")
+			for _, q := range []quad.Quad{
+				quad.Make(quad.IRI("a"), inc, quad.IRI("b"), quad.IRI("a:1")),
+				quad.Make(quad.IRI("a"), inc, quad.IRI("b"), quad.IRI("a:2")),
+				quad.Make(quad.IRI("a"), inc, quad.IRI("c"), quad.IRI("a:3")),
+				quad.Make(quad.IRI("c"), quad.IRI("other"), quad.IRI("a"), nil),
+			} {
+				if err := qs.AddQuad(q); err != nil {
+					t.Skipf("error creating quad: %v", err)
+				}
+			}
+			g := NewMultigraph(ctx, qs, inc, nil)
+			id := func(name string) int64 {
+				if n := g.NodeOf(quad.IRI(name)); n != nil {
+					return n.ID()
+				}
+				return -1
+			}
+			// Test
+			lines := g.Lines(id(test.from), id(test.to))
+			if n := lines.Len(); n != test.lines {
+				t.Fatalf("unexpected line count: expected %v, got %v", test.lines, n)
+			}
+			ids := make(map[int64]bool)
+			labels := make(map[quad.Value]bool)
+			for lines.Next() {
+				l := lines.Line()
+				if l.From().ID() != id(test.from) || l.To().ID() != id(test.to) {
+					t.Fatalf("unexpected line: %v", l)
+				}
+				if r := l.ReversedLine(); r.From().ID() != l.To().ID() || r.ID() != l.ID() {
+					t.Fatalf("unexpected reversed line: %v", r)
+				}
+				ids[l.ID()] = true
+				labels[l.(Line).Quad.Label] = true
+			}
+			if len(ids) != test.lines || len(labels) != test.lines {
+				t.Fatalf("lines not distinct: %v, %v", ids, labels)
+			}
+			// Line IDs are stable
+			if test.lines > 0 {
+				again := g.Lines(id(test.from), id(test.to))
+				for again.Next() {
+					if !ids[again.Line().ID()] {
+						t.Fatalf("unexpected line id: %v", again.Line().ID())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMultigraph_From(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	qs, err := cayley.NewMemoryGraph()
+	if err != nil {
+		t.Skipf("error creating graph: %v", err)
+	}
+	inc := quad.IRI("inc")
+	for _, q := range []quad.Quad{
+		quad.Make(quad.IRI("a"), inc, quad.IRI("b"), quad.IRI("a:1")),
+		quad.Make(quad.IRI("a"), inc, quad.IRI("b"), quad.IRI("a:2")),
+		quad.Make(quad.IRI("a"), inc, quad.IRI("c"), quad.IRI("a:3")),
+	} {
+		if err := qs.AddQuad(q); err != nil {
+			t.Skipf("error creating quad: %v", err)
+		}
+	}
+	g := NewMultigraph(ctx, qs, inc, nil)
+	a, b := g.NodeOf(quad.IRI("a")), g.NodeOf(quad.IRI("b"))
+	if a == nil || b == nil {
+		t.Fatalf("nodes not found")
+	}
+	// Test
+	tests := []struct {
+		name  string
+		nodes graph.Nodes
+		node  graph.Node
+		count int
+	}{
+		{"from", g.From(a.ID()), b, 2},
+		{"to", g.To(b.ID()), a, 1},
+	}
+	for _, test := range tests {
+		if n := test.nodes.Len(); n != test.count {
+			t.Fatalf("unexpected %v count: expected %v, got %v", test.name, test.count, n)
+		}
+		seen := 0
+		for test.nodes.Next() {
+			if test.nodes.Node().ID() == test.node.ID() {
+				seen++
+			}
+		}
+		if seen != 1 {
+			t.Fatalf("unexpected %v: %v seen %v times", test.name, test.node, seen)
+		}
+	}
+}
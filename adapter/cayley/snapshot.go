@@ -0,0 +1,138 @@
+package cayley
+
+import (
+	"context"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/iterator"
+	"gonum.org/v1/gonum/graph/simple"
+	"fmt"
+	"sort"
+)
+
+// Snapshot is an in-memory copy of a Directed, for algorithms that query the
+// graph many times. Its node IDs are those of the Directed it was taken of,
+// and it does not see later changes to the quadstore.
+type Snapshot struct {
+	ids   *Interner
+	nodes []graph.Node
+	// Indexed by node ID; from and to are sorted by ID
+	in   []bool
+	from [][]graph.Node
+	to   [][]graph.Node
+}
+
+// Materialise loads the graph of NewDirected.
+func Materialise(ctx context.Context, qs cayley.QuadStore, adj *path.Path) (*Snapshot, error) {
+	return NewDirected(ctx, qs, adj).Snapshot()
+}
+
+// Snapshot loads the nodes and edges of g in one pass over each.
+func (g *Directed) Snapshot() (*Snapshot, error) {
+	s := &Snapshot{ids: g.ids}
+	nodes := g.Nodes()
+	for nodes.Next() {
+		s.add(nodes.Node())
+	}
+	err := g.follow(g.set().Tag("from"), g.adj).Tag("to").Iterate(g.ctx).TagValues(g.qs, func(m map[string]quad.Value) {
+		u, v := s.add(g.node(m["from"])), s.add(g.node(m["to"]))
+		s.from[u.ID()] = append(s.from[u.ID()], v)
+		s.to[v.ID()] = append(s.to[v.ID()], u)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading edges: %v", err)
+	}
+	for _, adj := range [][][]graph.Node{s.from, s.to} {
+		for id, nodes := range adj {
+			adj[id] = dedupe(nodes)
+		}
+	}
+	return s, nil
+}
+
+// add adds node to s if it is not already in it.
+func (s *Snapshot) add(node graph.Node) graph.Node {
+	id := node.ID()
+	for int64(len(s.in)) <= id {
+		s.in = append(s.in, false)
+		s.from = append(s.from, nil)
+		s.to = append(s.to, nil)
+	}
+	if !s.in[id] {
+		s.in[id] = true
+		s.nodes = append(s.nodes, node)
+	}
+	return node
+}
+
+// dedupe sorts nodes by ID and removes repeats; paths may reach a node twice.
+func dedupe(nodes []graph.Node) []graph.Node {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	out := nodes[:0]
+	for _, n := range nodes {
+		if len(out) == 0 || out[len(out)-1].ID() != n.ID() {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func (s *Snapshot) has(id int64) bool {
+	return id >= 0 && id < int64(len(s.in)) && s.in[id]
+}
+
+// NodeOf returns the node of value v, or nil if v is not in the snapshot.
+func (s *Snapshot) NodeOf(v quad.Value) graph.Node {
+	id, ok := s.ids.Lookup(v)
+	if !ok || !s.has(id) {
+		return nil
+	}
+	return Value{Value: v, id: id}
+}
+
+func (s *Snapshot) Node(id int64) graph.Node {
+	if !s.has(id) {
+		return nil
+	}
+	return Value{Value: s.ids.Value(id), id: id}
+}
+
+func (s *Snapshot) Nodes() graph.Nodes {
+	return iterator.NewOrderedNodes(s.nodes)
+}
+
+func (s *Snapshot) From(id int64) graph.Nodes {
+	if !s.has(id) || len(s.from[id]) == 0 {
+		return graph.Empty
+	}
+	return iterator.NewOrderedNodes(s.from[id])
+}
+
+func (s *Snapshot) To(id int64) graph.Nodes {
+	if !s.has(id) || len(s.to[id]) == 0 {
+		return graph.Empty
+	}
+	return iterator.NewOrderedNodes(s.to[id])
+}
+
+func (s *Snapshot) HasEdgeFromTo(uid, vid int64) bool {
+	if !s.has(uid) {
+		return false
+	}
+	from := s.from[uid]
+	i := sort.Search(len(from), func(i int) bool { return from[i].ID() >= vid })
+	return i < len(from) && from[i].ID() == vid
+}
+
+func (s *Snapshot) HasEdgeBetween(xid, yid int64) bool {
+	return s.HasEdgeFromTo(xid, yid) || s.HasEdgeFromTo(yid, xid)
+}
+
+func (s *Snapshot) Edge(uid, vid int64) graph.Edge {
+	if !s.HasEdgeFromTo(uid, vid) {
+		return nil
+	}
+	return simple.Edge{F: s.Node(uid), T: s.Node(vid)}
+}
@@ -0,0 +1,82 @@
+package cayley
+
+import (
+	"testing"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"context"
+	"github.com/cayleygraph/cayley/graph/path"
+	"gonum.org/v1/gonum/graph/topo"
+	"gonum.org/v1/gonum/graph"
+	"reflect"
+)
+
+// idSet returns the IDs of nodes, iterating them rather than trusting Len.
+func idSet(nodes graph.Nodes) map[int64]bool {
+	ids := make(map[int64]bool)
+	for nodes.Next() {
+		ids[nodes.Node().ID()] = true
+	}
+	return ids
+}
+
+func TestDirected_Snapshot(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	qs, err := cayley.NewMemoryGraph()
+	if err != nil {
+		t.Skipf("error creating graph: %v", err)
+	}
+	for _, e := range []struct {
+		from string
+		to   string
+	}{
+		{"1", "2"},
+		{"1", "3"},
+		{"2", "3"},
+		{"3", "1"},
+		{"4", "4"},
+	} {
+		q := quad.Make(newValue(e.from), nil, newValue(e.to), nil)
+		if err := qs.AddQuad(q); err != nil {
+			t.Skipf("error creating edge: %v", err)
+		}
+	}
+	// Parallel edge through another predicate
+	if err := qs.AddQuad(quad.Make(newValue("1"), quad.IRI("p"), newValue("2"), nil)); err != nil {
+		t.Skipf("error creating edge: %v", err)
+	}
+	g := NewDirected(ctx, qs, path.StartMorphism().Out())
+	// Test
+	s, err := g.Snapshot()
+	if err != nil {
+		t.Fatalf("error taking snapshot: %v", err)
+	}
+	if a, e := idSet(s.Nodes()), idSet(g.Nodes()); !reflect.DeepEqual(a, e) {
+		t.Fatalf("unexpected node count: expected %v, got %v", e, a)
+	}
+	for _, x := range []string{"1", "2", "3", "4"} {
+		for _, y := range []string{"1", "2", "3", "4"} {
+			xid, yid := nodeID(g, x), nodeID(g, y)
+			if a, e := s.HasEdgeFromTo(xid, yid), g.HasEdgeFromTo(xid, yid); a != e {
+				t.Fatalf("unexpected edge %v-%v: expected %v, got %v", x, y, e, a)
+			}
+		}
+		id := nodeID(g, x)
+		if a, e := idSet(s.From(id)), idSet(g.From(id)); !reflect.DeepEqual(a, e) || s.From(id).Len() != len(e) {
+			t.Fatalf("unexpected from for %v: expected %v, got %v", x, e, a)
+		}
+		if a, e := idSet(s.To(id)), idSet(g.To(id)); !reflect.DeepEqual(a, e) || s.To(id).Len() != len(e) {
+			t.Fatalf("unexpected to for %v: expected %v, got %v", x, e, a)
+		}
+		if n := s.NodeOf(newValue(x)); n == nil || n.ID() != id || n.(Value).Value != newValue(x) {
+			t.Fatalf("unexpected node for %v: %v", x, n)
+		}
+	}
+	if s.Node(-1) != nil || s.NodeOf(newValue("5")) != nil {
+		t.Fatalf("unexpected node for missing value")
+	}
+	if a, e := topo.DirectedCyclesIn(s), topo.DirectedCyclesIn(g); !cycleSetEqual(e, a) {
+		t.Fatalf("unexpected cycles: expected %v, got %v", e, a)
+	}
+}
@@ -0,0 +1,55 @@
+package cayley
+
+import (
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// Undirected is a Directed with its edges taken in both directions. It shares
+// the node IDs of the Directed.
+type Undirected struct {
+	g *Directed
+}
+
+func NewUndirected(g *Directed) *Undirected {
+	return &Undirected{g: g}
+}
+
+func (u *Undirected) NodeOf(v quad.Value) graph.Node {
+	return u.g.NodeOf(v)
+}
+
+func (u *Undirected) Node(id int64) graph.Node {
+	return u.g.Node(id)
+}
+
+func (u *Undirected) Nodes() graph.Nodes {
+	return u.g.Nodes()
+}
+
+func (u *Undirected) From(id int64) graph.Nodes {
+	x := u.g.Node(id)
+	if x == nil {
+		return graph.Empty
+	}
+	p := path.StartPath(u.g.qs, x.(Value).Value)
+	it := u.g.follow(p, u.g.adj).Or(u.g.follow(p, u.g.adj.Reverse())).Unique().BuildIterator()
+	return NewIterator(u.g.ctx, it, u.g.qs, u.g.ids)
+}
+
+func (u *Undirected) HasEdgeBetween(xid, yid int64) bool {
+	return u.g.HasEdgeBetween(xid, yid)
+}
+
+func (u *Undirected) Edge(uid, vid int64) graph.Edge {
+	return u.EdgeBetween(uid, vid)
+}
+
+func (u *Undirected) EdgeBetween(xid, yid int64) graph.Edge {
+	if !u.HasEdgeBetween(xid, yid) {
+		return nil
+	}
+	return simple.Edge{F: u.g.Node(xid), T: u.g.Node(yid)}
+}
@@ -0,0 +1,60 @@
+package cayley
+
+import (
+	"testing"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"context"
+	"github.com/cayleygraph/cayley/graph/path"
+	"gonum.org/v1/gonum/graph/topo"
+	"sort"
+	"reflect"
+)
+
+func TestUndirected(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	qs, err := cayley.NewMemoryGraph()
+	if err != nil {
+		t.Skipf("error creating graph: %v", err)
+	}
+	for _, e := range []struct {
+		from string
+		to   string
+	}{
+		{"1", "2"},
+		{"3", "2"},
+		{"4", "5"},
+		{"6", "6"},
+	} {
+		q := quad.Make(newValue(e.from), nil, newValue(e.to), nil)
+		if err := qs.AddQuad(q); err != nil {
+			t.Skipf("error creating edge: %v", err)
+		}
+	}
+	d := NewDirected(ctx, qs, path.StartMorphism().Out())
+	g := NewUndirected(d)
+	// Test
+	if e, a := map[int64]bool{nodeID(d, "1"): true, nodeID(d, "3"): true}, idSet(g.From(nodeID(d, "2"))); !reflect.DeepEqual(e, a) {
+		t.Fatalf("unexpected from: expected %v, got %v", e, a)
+	}
+	if g.EdgeBetween(nodeID(d, "2"), nodeID(d, "1")) == nil || g.Edge(nodeID(d, "1"), nodeID(d, "2")) == nil {
+		t.Fatalf("edge not found")
+	}
+	if g.EdgeBetween(nodeID(d, "1"), nodeID(d, "3")) != nil {
+		t.Fatalf("unexpected edge")
+	}
+	var a [][]string
+	for _, c := range topo.ConnectedComponents(g) {
+		var names []string
+		for _, n := range c {
+			names = append(names, string(n.(Value).Value.(quad.String)))
+		}
+		sort.Strings(names)
+		a = append(a, names)
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i][0] < a[j][0] })
+	if e := [][]string{{"1", "2", "3"}, {"4", "5"}, {"6"}}; !reflect.DeepEqual(e, a) {
+		t.Fatalf("unexpected components: expected %v, got %v", e, a)
+	}
+}
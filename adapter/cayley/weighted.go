@@ -0,0 +1,108 @@
+package cayley
+
+import (
+	"context"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/quad"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+	"strconv"
+)
+
+// WeightFunc returns the weight of the edge from u to v.
+type WeightFunc func(u, v quad.Value) float64
+
+// ValueWeight weighs edges by the number pred gives their target, e.g. fs:size
+// for the size of an included header. Targets without one weigh def.
+func ValueWeight(ctx context.Context, qs cayley.QuadStore, pred quad.IRI, def float64) WeightFunc {
+	return func(_, v quad.Value) float64 {
+		w, err := path.StartPath(qs, v).Out(pred).Iterate(ctx).FirstValue(nil)
+		if err != nil {
+			return def
+		}
+		if f, ok := number(w); ok {
+			return f
+		}
+		return def
+	}
+}
+
+// LabelWeight weighs edges by the sum of the numbers prop gives the labels of
+// the quads from u to v with predicate pred. Labels without one count def, so
+// with a prop no label has, edges weigh def times the number of quads, e.g.
+// the number of directives including a header.
+func LabelWeight(ctx context.Context, qs cayley.QuadStore, pred, prop quad.IRI, def float64) WeightFunc {
+	return func(u, v quad.Value) (w float64) {
+		it := qs.QuadIterator(quad.Subject, qs.ValueOf(u))
+		defer it.Close()
+		for it.Next(ctx) {
+			q := qs.Quad(it.Result())
+			if q.Predicate != pred || q.Object != v || q.Label == nil {
+				continue
+			}
+			n, err := path.StartPath(qs, q.Label).Out(prop).Iterate(ctx).FirstValue(nil)
+			if f, ok := number(n); err == nil && ok {
+				w += f
+			} else {
+				w += def
+			}
+		}
+		return
+	}
+}
+
+// number returns v as a number, if it is one.
+func number(v quad.Value) (float64, bool) {
+	switch n := v.(type) {
+	case quad.Int:
+		return float64(n), true
+	case quad.Float:
+		return float64(n), true
+	case quad.String:
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// WeightedDirected is a Directed with edges weighed by a WeightFunc. As for
+// gonum's simple graphs, a node is self away from itself and absent away
+// from nodes it has no edge to.
+type WeightedDirected struct {
+	*Directed
+	weight WeightFunc
+	self   float64
+	absent float64
+}
+
+func NewWeightedDirected(g *Directed, weight WeightFunc, self, absent float64) *WeightedDirected {
+	return &WeightedDirected{
+		Directed: g,
+		weight:   weight,
+		self:     self,
+		absent:   absent,
+	}
+}
+
+func (g *WeightedDirected) Edge(uid, vid int64) graph.Edge {
+	return g.WeightedEdge(uid, vid)
+}
+
+func (g *WeightedDirected) WeightedEdge(uid, vid int64) graph.WeightedEdge {
+	if !g.HasEdgeFromTo(uid, vid) {
+		return nil
+	}
+	u, v := g.Node(uid), g.Node(vid)
+	return simple.WeightedEdge{F: u, T: v, W: g.weight(u.(Value).Value, v.(Value).Value)}
+}
+
+func (g *WeightedDirected) Weight(xid, yid int64) (w float64, ok bool) {
+	if xid == yid {
+		return g.self, true
+	}
+	if e := g.WeightedEdge(xid, yid); e != nil {
+		return e.Weight(), true
+	}
+	return g.absent, false
+}
@@ -0,0 +1,110 @@
+package cayley
+
+import (
+	"testing"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/quad"
+	"context"
+	"github.com/cayleygraph/cayley/graph/path"
+	gpath "gonum.org/v1/gonum/graph/path"
+	"math"
+	"reflect"
+)
+
+func TestWeightedDirected(t *testing.T) {
+	var (
+		inc  = quad.IRI("inc")
+		size = quad.IRI("size")
+		line = quad.IRI("line")
+	)
+	tests := []struct {
+		name   string
+		weight func(ctx context.Context, qs cayley.QuadStore) WeightFunc
+		ab     float64
+		path   []string
+		total  float64
+	}{
+		{
+			"value",
+			func(ctx context.Context, qs cayley.QuadStore) WeightFunc {
+				return ValueWeight(ctx, qs, size, 1)
+			},
+			10,
+			[]string{"a", "c", "d"},
+			3,
+		},
+		{
+			"label",
+			func(ctx context.Context, qs cayley.QuadStore) WeightFunc {
+				return LabelWeight(ctx, qs, inc, line, 1)
+			},
+			5,
+			[]string{"a", "b", "d"},
+			6,
+		},
+		{
+			"label count",
+			func(ctx context.Context, qs cayley.QuadStore) WeightFunc {
+				return LabelWeight(ctx, qs, inc, quad.IRI("none"), 1)
+			},
+			2,
+			[]string{"a", "c", "d"},
+			2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			qs, err := cayley.NewMemoryGraph()
+			if err != nil {
+				t.Skipf("error creating graph: %v", err)
+			}
+			for _, q := range []quad.Quad{
+				// a includes b twice, on lines 2 and 3
+				quad.Make(quad.IRI("a"), inc, quad.IRI("b"), quad.IRI("a:2")),
+				quad.Make(quad.IRI("a"), inc, quad.IRI("b"), quad.IRI("a:3")),
+				quad.Make(quad.IRI("a"), inc, quad.IRI("c"), quad.IRI("a:9")),
+				quad.Make(quad.IRI("b"), inc, quad.IRI("d"), quad.IRI("b:1")),
+				quad.Make(quad.IRI("c"), inc, quad.IRI("d"), quad.IRI("c:1")),
+				quad.Make(quad.IRI("a:2"), line, quad.Int(2), nil),
+				quad.Make(quad.IRI("a:3"), line, quad.Int(3), nil),
+				quad.Make(quad.IRI("a:9"), line, quad.Int(9), nil),
+				quad.Make(quad.IRI("b:1"), line, quad.Int(1), nil),
+				quad.Make(quad.IRI("c:1"), line, quad.Int(1), nil),
+				quad.Make(quad.IRI("b"), size, quad.Int(10), nil),
+				quad.Make(quad.IRI("c"), size, quad.Int(2), nil),
+			} {
+				if err := qs.AddQuad(q); err != nil {
+					t.Skipf("error creating quad: %v", err)
+				}
+			}
+			d := NewDirected(ctx, qs, path.StartMorphism().Out(inc))
+			g := NewWeightedDirected(d, test.weight(ctx, qs), 0, math.Inf(1))
+			id := func(name string) int64 {
+				return nodeID(d, quad.IRI(name))
+			}
+			// Test
+			if w, ok := g.Weight(id("a"), id("b")); !ok || w != test.ab {
+				t.Fatalf("unexpected weight a-b: expected %v, got %v (%v)", test.ab, w, ok)
+			}
+			if e := g.Edge(id("a"), id("b")); e == nil || e.(interface{ Weight() float64 }).Weight() != test.ab {
+				t.Fatalf("unexpected edge a-b: %v", e)
+			}
+			if w, ok := g.Weight(id("b"), id("a")); ok || !math.IsInf(w, 1) {
+				t.Fatalf("unexpected weight b-a: %v (%v)", w, ok)
+			}
+			if w, ok := g.Weight(id("a"), id("a")); !ok || w != 0 {
+				t.Fatalf("unexpected weight a-a: %v (%v)", w, ok)
+			}
+			nodes, total := gpath.DijkstraFrom(g.Node(id("a")), g).To(id("d"))
+			names := make([]string, len(nodes))
+			for n, node := range nodes {
+				names[n] = string(node.(Value).Value.(quad.IRI))
+			}
+			if !reflect.DeepEqual(names, test.path) || total != test.total {
+				t.Fatalf("unexpected shortest path: expected %v (%v), got %v (%v)", test.path, test.total, names, total)
+			}
+		})
+	}
+}
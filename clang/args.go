@@ -0,0 +1,46 @@
+package clang
+
+import (
+	"strings"
+)
+
+// Options are the preprocessor options of a compiler command line.
+type Options struct {
+	Quote   []string // -iquote
+	Angle   []string // -I
+	System  []string // -isystem
+	After   []string // -idirafter
+	Defines []string // -D
+}
+
+func ParseArgs(args []string) (o Options) {
+	flags := []struct {
+		name string
+		dst  *[]string
+	}{
+		{"-iquote", &o.Quote},
+		{"-isystem", &o.System},
+		{"-idirafter", &o.After},
+		{"-I", &o.Angle},
+		{"-D", &o.Defines},
+	}
+	for n := 0; n < len(args); n++ {
+		arg := args[n]
+		for _, f := range flags {
+			if !strings.HasPrefix(arg, f.name) {
+				continue
+			}
+			v := strings.TrimPrefix(arg, f.name)
+			if v == "" {
+				n++
+				if n >= len(args) {
+					break
+				}
+				v = args[n]
+			}
+			*f.dst = append(*f.dst, v)
+			break
+		}
+	}
+	return
+}
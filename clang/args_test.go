@@ -0,0 +1,64 @@
+package clang
+
+import (
+	"testing"
+	"reflect"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		opts Options
+	}{
+		{
+			"none",
+			[]string{"-c", "a.c", "-o", "a.o"},
+			Options{},
+		},
+		{
+			"joined",
+			[]string{"-Ia", "-iquoteb", "-isystemc", "-idirafterd", "-DE=1"},
+			Options{
+				Quote:   []string{"b"},
+				Angle:   []string{"a"},
+				System:  []string{"c"},
+				After:   []string{"d"},
+				Defines: []string{"E=1"},
+			},
+		},
+		{
+			"separate",
+			[]string{"-I", "a", "-iquote", "b", "-isystem", "c", "-idirafter", "d", "-D", "E"},
+			Options{
+				Quote:   []string{"b"},
+				Angle:   []string{"a"},
+				System:  []string{"c"},
+				After:   []string{"d"},
+				Defines: []string{"E"},
+			},
+		},
+		{
+			"order",
+			[]string{"-Ib", "-c", "-I", "a", "-Ic"},
+			Options{
+				Angle: []string{"b", "a", "c"},
+			},
+		},
+		{
+			"missing value",
+			[]string{"-Ia", "-I"},
+			Options{
+				Angle: []string{"a"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := ParseArgs(test.args)
+			if !reflect.DeepEqual(test.opts, a) {
+				t.Fatalf("unexpected options: expected %+v, got %+v", test.opts, a)
+			}
+		})
+	}
+}
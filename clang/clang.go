@@ -2,9 +2,68 @@ package clang
 
 import (
 	"github.com/phyrwork/mobius/fs"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/phyrwork/mobius/store"
+	"context"
 )
 
+const (
+	IncludeDirective = quad.IRI("clang:include")
+	Text             = quad.IRI("clang:text")
+	Line             = quad.IRI("clang:line")
+	Kind             = quad.IRI("clang:kind")
+)
+
+func init() {
+	// Directives are removed with the file they were scanned from
+	fs.RegisterOwned(IncludeDirective)
+}
+
+type Directive struct {
+	IRI  quad.IRI `quad:"@id"`
+	Text string   `quad:"clang:text"`
+	Line int      `quad:"clang:line"`
+	Kind int      `quad:"clang:kind,opt"`
+}
+
+func (d Directive) Include() Include {
+	return Include(d.Text)
+}
+
 type File struct {
 	fs.File
-	Includes []Include
-}
\ No newline at end of file
+	Includes []Directive `quad:"clang:include,opt"`
+}
+
+type fileIncludes struct {
+	IRI      quad.IRI    `quad:"@id"`
+	Includes []Directive `quad:"clang:include"`
+}
+
+func WriteIncludes(s *store.Store, f fs.File, includes ...Directive) error {
+	if len(includes) == 0 {
+		return nil
+	}
+	o := fileIncludes{
+		IRI:      f.IRI,
+		Includes: make([]Directive, len(includes)),
+	}
+	for n, d := range includes {
+		if len(d.IRI) == 0 {
+			d.IRI = s.GenerateIRI(nil)
+		}
+		o.Includes[n] = d
+	}
+	_, err := s.Insert(o)
+	return err
+}
+
+// ClearIncludes removes the include directives of f and the include edges
+// resolved from them.
+func ClearIncludes(ctx context.Context, s *store.Store, f File) error {
+	nodes := make([]quad.Value, len(f.Includes))
+	for n, d := range f.Includes {
+		nodes[n] = d.IRI
+	}
+	return s.RemoveNodes(ctx, nodes...)
+}
@@ -0,0 +1,41 @@
+package clang
+
+import (
+	"testing"
+	"context"
+)
+
+func TestWriteIncludes(t *testing.T) {
+	ctx := context.TODO()
+	s := NewFs(t)
+	f, err := s.Create(ctx, "a.c")
+	if err != nil {
+		t.Skipf("error creating test file: %v", err)
+	}
+	e := map[int]string{
+		1: "#include <b.h>",
+		3: "#include \"c.h\"",
+	}
+	includes := make([]Directive, 0)
+	for line, text := range e {
+		includes = append(includes, Directive{Text: text, Line: line})
+	}
+	if err := WriteIncludes(s.Store, f, includes...); err != nil {
+		t.Fatalf("error writing includes: %v", err)
+	}
+	var a File
+	if _, err := s.Lookup(ctx, &a, "a.c"); err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if a.IRI != f.IRI {
+		t.Fatalf("unexpected file: expected %v, got %v", f.IRI, a.IRI)
+	}
+	if len(a.Includes) != len(e) {
+		t.Fatalf("unexpected include count: expected %v, got %v", len(e), len(a.Includes))
+	}
+	for _, d := range a.Includes {
+		if text, ok := e[d.Line]; !ok || text != d.Text {
+			t.Fatalf("unexpected include: %v", d)
+		}
+	}
+}
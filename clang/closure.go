@@ -0,0 +1,65 @@
+package clang
+
+import (
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/phyrwork/mobius/fs"
+	"github.com/cayleygraph/cayley/graph/path"
+	"fmt"
+	"sort"
+)
+
+// Reach is a node in an include closure and the fewest include edges between
+// it and the start of the closure.
+type Reach struct {
+	Node  quad.Value
+	Depth int
+}
+
+func closure(ctx context.Context, s *fs.Fs, node quad.Value, via *path.Path, maxDepth int) (nodes []Reach, err error) {
+	if maxDepth <= 0 {
+		maxDepth = -1
+	}
+	p := path.StartPath(s.Store.Graph, node).FollowRecursive(via, maxDepth, []string{"depth"}).Tag("node")
+	depths := make(map[quad.Value]int)
+	err = p.Iterate(ctx).Paths(false).TagValues(nil, func(tags map[string]quad.Value) {
+		v := tags["node"]
+		depth, ok := tags["depth"].(quad.Int)
+		if v == nil || v == node || !ok {
+			return
+		}
+		if d, ok := depths[v]; ok && d <= int(depth) {
+			return
+		}
+		depths[v] = int(depth)
+	})
+	if err != nil {
+		err = fmt.Errorf("error following includes: %v", err)
+		return
+	}
+	for v, depth := range depths {
+		nodes = append(nodes, Reach{
+			Node:  v,
+			Depth: depth,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Depth != nodes[j].Depth {
+			return nodes[i].Depth < nodes[j].Depth
+		}
+		return nodes[i].Node.String() < nodes[j].Node.String()
+	})
+	return
+}
+
+// Closure returns every file node included by node directly or indirectly.
+// A maxDepth greater than zero limits the number of include edges followed.
+func Closure(ctx context.Context, s *fs.Fs, node quad.Value, maxDepth int) ([]Reach, error) {
+	return closure(ctx, s, node, IncludesMorphism, maxDepth)
+}
+
+// ReverseClosure returns every file node that includes node directly or indirectly.
+// A maxDepth greater than zero limits the number of include edges followed.
+func ReverseClosure(ctx context.Context, s *fs.Fs, node quad.Value, maxDepth int) ([]Reach, error) {
+	return closure(ctx, s, node, IncludedByMorphism, maxDepth)
+}
@@ -0,0 +1,160 @@
+package clang
+
+import (
+	"testing"
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/phyrwork/mobius/fs"
+	"reflect"
+)
+
+func NewIncludeGraph(t *testing.T, edges [][2]string) (*fs.Fs, map[string]quad.Value) {
+	ctx := context.TODO()
+	s := NewFs(t)
+	files := make(map[string]quad.Value)
+	qw := graph.NewWriter(s.Store.Graph)
+	for _, e := range edges {
+		for _, p := range e {
+			if _, ok := files[p]; ok {
+				continue
+			}
+			f, err := s.Create(ctx, p)
+			if err != nil {
+				t.Skipf("error creating test file %v: %v", p, err)
+			}
+			files[p] = f.IRI
+		}
+		if err := qw.WriteQuad(quad.Make(files[e[0]], Includes, files[e[1]], nil)); err != nil {
+			t.Skipf("error writing include edge: %v", err)
+		}
+	}
+	if err := qw.Flush(); err != nil {
+		t.Skipf("error writing include edges: %v", err)
+	}
+	return s, files
+}
+
+func TestClosure(t *testing.T) {
+	edges := [][2]string{
+		{"a.c", "b.h"},
+		{"a.c", "c.h"},
+		{"b.h", "d.h"},
+		{"c.h", "d.h"},
+		{"d.h", "e.h"},
+		{"e.h", "b.h"},
+		{"f.c", "a.c"},
+	}
+	tests := []struct {
+		name     string
+		src      string
+		maxDepth int
+		closure  map[string]int
+	}{
+		{
+			"unlimited",
+			"a.c",
+			0,
+			map[string]int{"b.h": 1, "c.h": 1, "d.h": 2, "e.h": 3},
+		},
+		{
+			"limited",
+			"a.c",
+			2,
+			map[string]int{"b.h": 1, "c.h": 1, "d.h": 2},
+		},
+		{
+			"cycle",
+			"d.h",
+			0,
+			map[string]int{"e.h": 1, "b.h": 2},
+		},
+		{
+			"none",
+			"e.h",
+			1,
+			map[string]int{"b.h": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+			s, files := NewIncludeGraph(t, edges)
+			e := make(map[quad.Value]int)
+			for p, depth := range test.closure {
+				e[files[p]] = depth
+			}
+			nodes, err := Closure(ctx, s, files[test.src], test.maxDepth)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			a := make(map[quad.Value]int)
+			for n, r := range nodes {
+				if n > 0 && nodes[n-1].Depth > r.Depth {
+					t.Fatalf("closure not ordered by depth: %v", nodes)
+				}
+				a[r.Node] = r.Depth
+			}
+			if !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected closure: expected %v, got %v", e, a)
+			}
+		})
+	}
+}
+
+func TestReverseClosure(t *testing.T) {
+	edges := [][2]string{
+		{"a.c", "b.h"},
+		{"a.c", "c.h"},
+		{"b.h", "d.h"},
+		{"c.h", "d.h"},
+		{"f.c", "a.c"},
+		{"g.c", "c.h"},
+	}
+	tests := []struct {
+		name     string
+		src      string
+		maxDepth int
+		closure  map[string]int
+	}{
+		{
+			"unlimited",
+			"d.h",
+			0,
+			map[string]int{"b.h": 1, "c.h": 1, "a.c": 2, "g.c": 2, "f.c": 3},
+		},
+		{
+			"limited",
+			"d.h",
+			2,
+			map[string]int{"b.h": 1, "c.h": 1, "a.c": 2, "g.c": 2},
+		},
+		{
+			"none",
+			"f.c",
+			0,
+			map[string]int{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+			s, files := NewIncludeGraph(t, edges)
+			e := make(map[quad.Value]int)
+			for p, depth := range test.closure {
+				e[files[p]] = depth
+			}
+			nodes, err := ReverseClosure(ctx, s, files[test.src], test.maxDepth)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			a := make(map[quad.Value]int)
+			for _, r := range nodes {
+				a[r.Node] = r.Depth
+			}
+			if !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected closure: expected %v, got %v", e, a)
+			}
+		})
+	}
+}
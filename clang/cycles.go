@@ -0,0 +1,79 @@
+package clang
+
+import (
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/phyrwork/mobius/fs"
+	adapter "github.com/phyrwork/mobius/adapter/cayley"
+	"gonum.org/v1/gonum/graph/topo"
+	"gonum.org/v1/gonum/graph"
+	"fmt"
+	"sort"
+)
+
+func values(nodes []graph.Node) []quad.Value {
+	values := make([]quad.Value, len(nodes))
+	for n, node := range nodes {
+		values[n] = node.(adapter.Value).Value
+	}
+	return values
+}
+
+// includeGraph loads the include graph into memory.
+func includeGraph(ctx context.Context, s *fs.Fs) (*adapter.Snapshot, error) {
+	g, err := adapter.Materialise(ctx, s.Store.Graph.QuadStore, IncludesMorphism)
+	if err != nil {
+		return nil, fmt.Errorf("error loading include graph: %v", err)
+	}
+	return g, nil
+}
+
+// Cycles returns every elementary include cycle. Each cycle starts and ends
+// with the same node.
+func Cycles(ctx context.Context, s *fs.Fs) (cycles [][]quad.Value, err error) {
+	g, err := includeGraph(ctx, s)
+	if err != nil {
+		return
+	}
+	for _, c := range topo.DirectedCyclesIn(g) {
+		cycles = append(cycles, values(c))
+	}
+	return
+}
+
+// Components returns the strongly connected components of the include graph
+// that contain a cycle.
+func Components(ctx context.Context, s *fs.Fs) (components [][]quad.Value, err error) {
+	g, err := includeGraph(ctx, s)
+	if err != nil {
+		return
+	}
+	for _, c := range topo.TarjanSCC(g) {
+		if len(c) == 1 && !g.HasEdgeFromTo(c[0].ID(), c[0].ID()) {
+			continue
+		}
+		components = append(components, values(c))
+	}
+	return
+}
+
+// Lines returns the line numbers of the directives in from that include to.
+func Lines(ctx context.Context, s *fs.Fs, from quad.Value, to quad.Value) (lines []int, err error) {
+	depends, err := ReadDepends(ctx, s.Store, from)
+	if err != nil {
+		return
+	}
+	for _, dep := range depends {
+		if dep.To != to || dep.Directive == nil {
+			continue
+		}
+		var d Directive
+		if err = s.Store.Select(ctx, &d, dep.Directive); err != nil {
+			err = fmt.Errorf("error reading directive %v: %v", dep.Directive, err)
+			return
+		}
+		lines = append(lines, d.Line)
+	}
+	sort.Ints(lines)
+	return
+}
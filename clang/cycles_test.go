@@ -0,0 +1,156 @@
+package clang
+
+import (
+	"testing"
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"reflect"
+	"sort"
+	"fmt"
+)
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name   string
+		edges  [][2]string
+		cycles [][]string
+	}{
+		{
+			"none",
+			[][2]string{
+				{"a.c", "b.h"},
+				{"b.h", "c.h"},
+			},
+			nil,
+		},
+		{
+			"simple",
+			[][2]string{
+				{"a.c", "b.h"},
+				{"b.h", "c.h"},
+				{"c.h", "b.h"},
+			},
+			[][]string{
+				{"b.h", "c.h"},
+			},
+		},
+		{
+			"overlapping",
+			[][2]string{
+				{"a.h", "b.h"},
+				{"b.h", "a.h"},
+				{"b.h", "c.h"},
+				{"c.h", "a.h"},
+			},
+			[][]string{
+				{"a.h", "b.h"},
+				{"a.h", "b.h", "c.h"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+			s, files := NewIncludeGraph(t, test.edges)
+			names := make(map[quad.Value]string)
+			for p, node := range files {
+				names[node] = p
+			}
+			// Compare cycles as sorted sets of names
+			set := func(nodes []string) string {
+				sort.Strings(nodes)
+				return fmt.Sprint(nodes)
+			}
+			e := make(map[string]struct{})
+			for _, c := range test.cycles {
+				e[set(append([]string{}, c...))] = struct{}{}
+			}
+			a := make(map[string]struct{})
+			cycles, err := Cycles(ctx, s)
+			if err != nil {
+				t.Fatalf("error finding cycles: %v", err)
+			}
+			for _, c := range cycles {
+				if c[0] != c[len(c)-1] {
+					t.Fatalf("cycle not closed: %v", c)
+				}
+				var nodes []string
+				for _, node := range c[1:] {
+					nodes = append(nodes, names[node])
+				}
+				a[set(nodes)] = struct{}{}
+			}
+			if (len(e) > 0 || len(a) > 0) && !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected cycles: expected %v, got %v", e, a)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	ctx := context.TODO()
+	s := NewFs(t)
+	src, err := s.Create(ctx, "a.c")
+	if err != nil {
+		t.Skipf("error creating test file: %v", err)
+	}
+	dst, err := s.Create(ctx, "b.h")
+	if err != nil {
+		t.Skipf("error creating test file: %v", err)
+	}
+	includes := []Directive{
+		{Text: "#include \"b.h\"", Line: 7, Kind: IncludeUser},
+		{Text: "#include \"b.h\"", Line: 2, Kind: IncludeUser},
+		{Text: "#include \"c.h\"", Line: 4, Kind: IncludeUser},
+	}
+	if err := WriteIncludes(s.Store, src, includes...); err != nil {
+		t.Skipf("error writing includes: %v", err)
+	}
+	var f File
+	if _, err := s.Lookup(ctx, &f, "a.c"); err != nil {
+		t.Skipf("error reading test file: %v", err)
+	}
+	Link(ctx, s, OrderedPath{}, f)
+	a, err := Lines(ctx, s, src.IRI, dst.IRI)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := []int{2, 7}; !reflect.DeepEqual(e, a) {
+		t.Fatalf("unexpected lines: expected %v, got %v", e, a)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	ctx := context.TODO()
+	s, files := NewIncludeGraph(t, [][2]string{
+		{"a.c", "b.h"},
+		{"b.h", "c.h"},
+		{"c.h", "b.h"},
+		{"c.h", "d.h"},
+		{"e.h", "e.h"},
+	})
+	e := map[quad.Value]int{
+		files["b.h"]: 0,
+		files["c.h"]: 0,
+		files["e.h"]: 1,
+	}
+	components, err := Components(ctx, s)
+	if err != nil {
+		t.Fatalf("error finding components: %v", err)
+	}
+	if len(components) != 2 {
+		t.Fatalf("unexpected components: %v", components)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+	a := make(map[quad.Value]int)
+	for n, c := range components {
+		for _, node := range c {
+			a[node] = n
+		}
+	}
+	if !reflect.DeepEqual(e, a) {
+		t.Fatalf("unexpected components: expected %v, got %v", e, a)
+	}
+}
@@ -6,8 +6,28 @@ import (
 	"github.com/cayleygraph/cayley/quad"
 	"fmt"
 	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/phyrwork/mobius/store"
+	"github.com/cayleygraph/cayley/graph"
+	"strings"
 )
 
+const (
+	Includes = quad.IRI("clang:includes")
+)
+
+var (
+	IncludesMorphism   = path.StartMorphism().Out(Includes)
+	IncludedByMorphism = path.StartMorphism().In(Includes)
+)
+
+// Depend is an include edge. The edge quad is labelled with the directive node
+// it was resolved from, which carries the line number and include kind.
+type Depend struct {
+	From      quad.Value
+	To        quad.Value
+	Directive quad.Value
+}
+
 func IncludePath(s *fs.Fs, dirs ...string) (p *path.Path, errs []error) {
 	nodes := make([]quad.Value, 0)
 	for _, p := range dirs {
@@ -37,31 +57,275 @@ func ResolveInclude(ctx context.Context, p *path.Path, includes ...Include) (dep
 		errs = []error{fmt.Errorf("nil include path")}
 		return
 	}
+	return ResolveIncludeWith(ctx, PathResolver{Path: p}, includes...)
+}
+
+func ResolveIncludeWith(ctx context.Context, r Resolver, includes ...Include) (depends map[quad.Value]struct{}, errs []error) {
+	if r == nil {
+		// No path to resolve against
+		errs = []error{fmt.Errorf("nil include path")}
+		return
+	}
 	depends = make(map[quad.Value]struct{})
 	for _, i := range includes {
-		ip, err := i.Path()
+		node, err := r.Resolve(ctx, i)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("error in include %v: %v", i, err))
+			errs = append(errs, err)
 			continue
 		}
-		nodes, err := p.Follow(fs.Path(ip).StartMorphism()).Iterate(ctx).AllValues(nil)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("error following include path: %v", err))
+		depends[node] = struct{}{}
+	}
+	return
+}
+
+// ResolveIncludeFrom resolves includes as included by src. User includes are
+// looked up relative to the directory of src before falling back to r.
+func ResolveIncludeFrom(ctx context.Context, s *fs.Fs, src quad.Value, r Resolver, includes ...Include) (depends map[quad.Value]struct{}, errs []error) {
+	depends = make(map[quad.Value]struct{})
+	dir := path.StartPath(s.Store.Graph, src).Follow(fs.UpMorphism)
+	for _, i := range includes {
+		if i.IsUser() {
+			ip, err := i.Path()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error in include %v: %v", i, err))
+				continue
+			}
+			node, err := dir.Follow(fs.Path(ip).StartMorphism()).Iterate(ctx).FirstValue(nil)
+			if err == nil && node == nil && s.FoldCase {
+				node, err = dir.Follow(fs.Path(ip).StartFoldMorphism()).Iterate(ctx).FirstValue(nil)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error following include path: %v", err))
+				continue
+			}
+			if node != nil {
+				depends[node] = struct{}{}
+				continue
+			}
+		}
+		found, ferrs := ResolveIncludeWith(ctx, r, i)
+		for node := range found {
+			depends[node] = struct{}{}
+		}
+		errs = append(errs, ferrs...)
+	}
+	return
+}
+
+func WriteDepends(s *store.Store, f fs.File, d Directive, depends ...quad.Value) error {
+	if len(d.IRI) == 0 {
+		return fmt.Errorf("directive %v not stored", d.Text)
+	}
+	qw := graph.NewWriter(s.Graph)
+	for _, node := range depends {
+		q := quad.Make(f.IRI, Includes, node, d.IRI)
+		if err := qw.WriteQuad(q); err != nil {
+			return err
+		}
+	}
+	return qw.Flush()
+}
+
+func ReadDepends(ctx context.Context, s *store.Store, node quad.Value) (depends []Depend, err error) {
+	qs := s.Graph.QuadStore
+	v := qs.ValueOf(node)
+	if v == nil {
+		return
+	}
+	it := qs.QuadIterator(quad.Subject, v)
+	defer it.Close()
+	for it.Next(ctx) {
+		q := qs.Quad(it.Result())
+		if q.Predicate != Includes {
 			continue
 		}
-		switch len(nodes) {
-		case 0:
-			errs = append(errs, fmt.Errorf("%v not resolved", i))
-		case 1:
-			depends[nodes[0]] = struct{}{}
-		default:
-			ids := make([]string, len(nodes))
-			for n, node := range nodes {
-				ids[n] = node.String()
+		depends = append(depends, Depend{
+			From:      q.Subject,
+			To:        q.Object,
+			Directive: q.Label,
+		})
+	}
+	err = it.Err()
+	return
+}
+
+// DirectiveDepends returns the nodes the include edges resolved from directive
+// point to.
+func DirectiveDepends(ctx context.Context, s *store.Store, directive quad.Value) (nodes []quad.Value, err error) {
+	err = eachDepend(ctx, s, directive, func(q quad.Quad) {
+		nodes = append(nodes, q.Object)
+	})
+	return
+}
+
+// ClearDepends removes the include edges resolved from directives.
+func ClearDepends(ctx context.Context, s *store.Store, directives ...quad.Value) error {
+	tx := graph.NewTransaction()
+	for _, d := range directives {
+		if err := eachDepend(ctx, s, d, func(q quad.Quad) {
+			tx.RemoveQuad(q)
+		}); err != nil {
+			return err
+		}
+	}
+	return s.Graph.ApplyTransaction(tx)
+}
+
+// eachDepend calls fn with each include edge labelled with directive.
+func eachDepend(ctx context.Context, s *store.Store, directive quad.Value, fn func(q quad.Quad)) error {
+	qs := s.Graph.QuadStore
+	v := qs.ValueOf(directive)
+	if v == nil {
+		return nil
+	}
+	it := qs.QuadIterator(quad.Label, v)
+	defer it.Close()
+	for it.Next(ctx) {
+		if q := qs.Quad(it.Result()); q.Predicate == Includes {
+			fn(q)
+		}
+	}
+	return it.Err()
+}
+
+// resolveDirective returns the nodes directive d of f resolves to.
+func resolveDirective(ctx context.Context, s *fs.Fs, r Resolver, f File, d Directive) (nodes []quad.Value, errs []error) {
+	depends, rerrs := ResolveIncludeFrom(ctx, s, f.IRI, r, d.Include())
+	for _, err := range rerrs {
+		errs = append(errs, fmt.Errorf("line %v: %v", d.Line, err))
+	}
+	nodes = make([]quad.Value, 0, len(depends))
+	for node := range depends {
+		nodes = append(nodes, node)
+	}
+	return
+}
+
+// LinkDirective replaces the include edges resolved from directive d of f
+// with those to the nodes it now resolves to.
+func LinkDirective(ctx context.Context, s *fs.Fs, r Resolver, f File, d Directive) (nodes []quad.Value, errs []error) {
+	nodes, errs = resolveDirective(ctx, s, r, f, d)
+	if err := ClearDepends(ctx, s.Store, d.IRI); err != nil {
+		errs = append(errs, fmt.Errorf("line %v: error clearing depends: %v", d.Line, err))
+		return
+	}
+	if err := WriteDepends(s.Store, f.File, d, nodes...); err != nil {
+		errs = append(errs, fmt.Errorf("line %v: error writing depends: %v", d.Line, err))
+	}
+	return
+}
+
+func Link(ctx context.Context, s *fs.Fs, r Resolver, f File) (errs []error) {
+	for _, d := range f.Includes {
+		_, derrs := LinkDirective(ctx, s, r, f, d)
+		errs = append(errs, derrs...)
+	}
+	return
+}
+
+// Changes are the changes made to the trees since their includes were
+// linked. They select the directives that may now resolve differently.
+type Changes struct {
+	// Files are the nodes of the files created or modified. All of their
+	// directives are relinked.
+	Files map[quad.Value]struct{}
+	// Names are the base names of the paths created, in lower case. A
+	// directive naming one may now resolve to it, e.g. if it is beside the
+	// including file or earlier in the search path.
+	Names map[string]struct{}
+	// Links are the former targets of symlinks retargeted or removed. A
+	// directive that resolved to one, or below one, may have resolved
+	// through the symlink.
+	Links map[quad.Value]struct{}
+}
+
+func NewChanges() Changes {
+	return Changes{
+		Files: make(map[quad.Value]struct{}),
+		Names: make(map[string]struct{}),
+		Links: make(map[quad.Value]struct{}),
+	}
+}
+
+// AddCreated adds the node created at path.
+func (c Changes) AddCreated(node quad.Value, path string) {
+	c.Files[node] = struct{}{}
+	c.Names[strings.ToLower(fs.Path(path).Base())] = struct{}{}
+}
+
+// AddModified adds the node of a file whose content changed.
+func (c Changes) AddModified(node quad.Value) {
+	c.Files[node] = struct{}{}
+}
+
+// AddLinks adds the symlinks whose final target changed from before to
+// after, as returned by fs.Fs.LinkTargets.
+func (c Changes) AddLinks(before, after map[quad.Value]quad.Value) {
+	for link, target := range before {
+		if after[link] != target {
+			c.Links[target] = struct{}{}
+		}
+	}
+}
+
+// Stale reports whether directive d of f must be relinked: f changed, d
+// names a path created, d was not resolved or d may have resolved through a
+// symlink since retargeted. Edges to removed files are removed with them, so
+// their directives are unresolved.
+func (c Changes) Stale(ctx context.Context, s *store.Store, f File, d Directive) (bool, error) {
+	if _, ok := c.Files[f.IRI]; ok {
+		return true, nil
+	}
+	if ip, err := d.Include().Path(); err == nil {
+		if _, ok := c.Names[strings.ToLower(fs.Path(ip).Base())]; ok {
+			return true, nil
+		}
+	}
+	nodes, err := DirectiveDepends(ctx, s, d.IRI)
+	if err != nil || len(nodes) == 0 || len(c.Links) == 0 {
+		return len(nodes) == 0, err
+	}
+	ups, err := path.StartPath(s.Graph, nodes...).FollowRecursive(fs.UpMorphism, -1, nil).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		return false, err
+	}
+	for _, node := range append(nodes, ups...) {
+		if _, ok := c.Links[node]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func LinkAll(ctx context.Context, s *fs.Fs, r Resolver) (errs []error) {
+	return RelinkAll(ctx, s, r, nil)
+}
+
+// RelinkAll relinks the directives made stale by changes, or every directive
+// if changes is nil. The edges of other directives are kept.
+func RelinkAll(ctx context.Context, s *fs.Fs, r Resolver, changes *Changes) (errs []error) {
+	var files []File
+	if err := s.Store.Select(ctx, &files); err != nil {
+		errs = append(errs, fmt.Errorf("error reading files: %v", err))
+		return
+	}
+	for _, f := range files {
+		for _, d := range f.Includes {
+			if changes != nil {
+				stale, err := changes.Stale(ctx, s.Store, f, d)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%v: line %v: error reading depends: %v", f.Name, d.Line, err))
+					continue
+				}
+				if !stale {
+					continue
+				}
+			}
+			_, derrs := LinkDirective(ctx, s, r, f, d)
+			for _, err := range derrs {
+				errs = append(errs, fmt.Errorf("%v: %v", f.Name, err))
 			}
-			errs = append(errs, fmt.Errorf("%v resolved ambiguously: %v", i, ids))
-			continue
 		}
 	}
 	return
-}
\ No newline at end of file
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"github.com/cayleygraph/cayley/quad"
 	"reflect"
+	"github.com/cayleygraph/cayley/graph/path"
 )
 
 func NewStore(t *testing.T) *store.Store {
@@ -147,3 +148,217 @@ func TestResolveInclude(t *testing.T) {
 	}
 }
 
+func TestResolveIncludeFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		includes []string
+		files    []string
+		path     []string
+		depends  []string
+		err      bool
+	}{
+		{
+			"user beside source",
+			"a/b.c",
+			[]string{"#include \"c.h\""},
+			[]string{"a/c.h"},
+			[]string{},
+			[]string{"a/c.h"},
+			false,
+		},
+		{
+			"user below source",
+			"a/b.c",
+			[]string{"#include \"c/d.h\""},
+			[]string{"a/c/d.h"},
+			[]string{},
+			[]string{"a/c/d.h"},
+			false,
+		},
+		{
+			"user beside source before path",
+			"a/b.c",
+			[]string{"#include \"c.h\""},
+			[]string{"a/c.h", "d/c.h"},
+			[]string{"d"},
+			[]string{"a/c.h"},
+			false,
+		},
+		{
+			"user on path",
+			"a/b.c",
+			[]string{"#include \"c.h\""},
+			[]string{"d/c.h"},
+			[]string{"d"},
+			[]string{"d/c.h"},
+			false,
+		},
+		{
+			"sys beside source",
+			"a/b.c",
+			[]string{"#include <c.h>"},
+			[]string{"a/c.h"},
+			[]string{"d"},
+			[]string{},
+			true,
+		},
+		{
+			"sys on path",
+			"a/b.c",
+			[]string{"#include <c.h>"},
+			[]string{"a/c.h", "d/c.h"},
+			[]string{"d"},
+			[]string{"d/c.h"},
+			false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+			// Setup
+			s := NewFs(t)
+			includes := make([]Include, len(test.includes))
+			for n, i := range test.includes {
+				includes[n] = Include(i)
+			}
+			src, err := s.Create(ctx, test.src)
+			if err != nil {
+				t.Skipf("error creating test file %v: %v", test.src, err)
+			}
+			for _, p := range test.files {
+				_, err := s.Create(ctx, p)
+				if err != nil {
+					t.Skipf("error creating test file %v: %v", p, err)
+				}
+			}
+			e := make(map[quad.Value]struct{})
+			for _, p := range test.depends {
+				node, err := s.Lookup(ctx, nil, p)
+				if err != nil {
+					t.Skipf("error looking up test file %v: %v", p, err)
+				}
+				e[node] = struct{}{}
+			}
+			p, _ := IncludePath(s, test.path...)
+			// Test
+			a, errs := ResolveIncludeFrom(ctx, s, src.IRI, PathResolver{Path: p}, includes...)
+			if (len(e) > 0 || len(a) > 0) && !reflect.DeepEqual(e, a) { // DeepEqual doesn't like two len() = 0
+				t.Fatalf("unexpected resolutions: expected %v, got %v", e, a)
+			}
+			if len(errs) > 0 != test.err {
+				t.Fatalf("unexpected error status: %v", errs)
+			}
+		})
+	}
+}
+
+func TestLink(t *testing.T) {
+	ctx := context.TODO()
+	// Setup
+	s := NewFs(t)
+	src, err := s.Create(ctx, "a/b.c")
+	if err != nil {
+		t.Skipf("error creating test file: %v", err)
+	}
+	for _, p := range []string{"c/d.h", "c/e.h"} {
+		if _, err := s.Create(ctx, p); err != nil {
+			t.Skipf("error creating test file %v: %v", p, err)
+		}
+	}
+	includes := []Directive{
+		{Text: "#include <d.h>", Line: 1, Kind: IncludeSys},
+		{Text: "#include \"e.h\"", Line: 2, Kind: IncludeUser},
+		{Text: "#include <f.h>", Line: 3, Kind: IncludeSys},
+	}
+	if err := WriteIncludes(s.Store, src, includes...); err != nil {
+		t.Skipf("error writing includes: %v", err)
+	}
+	var f File
+	if _, err := s.Lookup(ctx, &f, "a/b.c"); err != nil {
+		t.Skipf("error reading test file: %v", err)
+	}
+	p, errs := IncludePath(s, "c")
+	if errs != nil {
+		t.Skipf("error initializing include path: %v", errs)
+	}
+	// Test
+	errs = LinkAll(ctx, s, PathResolver{Path: p})
+	if len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	e := make(map[quad.Value]int)
+	for p, line := range map[string]int{"c/d.h": 1, "c/e.h": 2} {
+		node, err := s.Lookup(ctx, nil, p)
+		if err != nil {
+			t.Skipf("error looking up test file %v: %v", p, err)
+		}
+		e[node] = line
+	}
+	nodes, err := path.StartPath(s.Store.Graph, src.IRI).Follow(IncludesMorphism).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		t.Fatalf("error following includes: %v", err)
+	}
+	if len(nodes) != len(e) {
+		t.Fatalf("unexpected includes: %v", nodes)
+	}
+	depends, err := ReadDepends(ctx, s.Store, src.IRI)
+	if err != nil {
+		t.Fatalf("error reading depends: %v", err)
+	}
+	if len(depends) != len(e) {
+		t.Fatalf("unexpected depends: %v", depends)
+	}
+	for _, dep := range depends {
+		var d Directive
+		if err := s.Store.Select(ctx, &d, dep.Directive); err != nil {
+			t.Fatalf("error reading directive %v: %v", dep.Directive, err)
+		}
+		if line, ok := e[dep.To]; !ok || line != d.Line {
+			t.Fatalf("unexpected depend: %v (line %v)", dep.To, d.Line)
+		}
+		if d.Kind != Include(d.Text).Kind() {
+			t.Fatalf("unexpected kind: %v", d.Kind)
+		}
+	}
+}
+
+func TestLink_RootPrefix(t *testing.T) {
+	ctx := context.TODO()
+	// Setup
+	s := NewFs(t)
+	sdk, err := fs.NewNamedFs(ctx, s.Store, "sdk")
+	if err != nil {
+		t.Skipf("error creating named root: %v", err)
+	}
+	e, err := sdk.Create(ctx, "include/x.h")
+	if err != nil {
+		t.Skipf("error creating test file: %v", err)
+	}
+	// Same path in the default root
+	if _, err := s.Create(ctx, "include/x.h"); err != nil {
+		t.Skipf("error creating test file: %v", err)
+	}
+	src, err := s.Create(ctx, "a/b.c")
+	if err != nil {
+		t.Skipf("error creating test file: %v", err)
+	}
+	if err := WriteIncludes(s.Store, src, Directive{Text: "#include <x.h>", Line: 1, Kind: IncludeSys}); err != nil {
+		t.Skipf("error writing includes: %v", err)
+	}
+	p, errs := IncludePath(s, "@sdk/include")
+	if errs != nil {
+		t.Skipf("error initializing include path: %v", errs)
+	}
+	// Test
+	if errs := LinkAll(ctx, s, PathResolver{Path: p}); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	depends, err := ReadDepends(ctx, s.Store, src.IRI)
+	if err != nil {
+		t.Fatalf("error reading depends: %v", err)
+	}
+	if len(depends) != 1 || depends[0].To != e.IRI {
+		t.Fatalf("unexpected depends: expected %v, got %v", e.IRI, depends)
+	}
+}
@@ -0,0 +1,140 @@
+package clang
+
+import (
+	"context"
+	"github.com/phyrwork/mobius/fs"
+	"github.com/cayleygraph/cayley/quad"
+	"fmt"
+	"strings"
+	"github.com/cayleygraph/cayley/graph"
+)
+
+// Mismatch is an include edge whose include text names the included file
+// with different case, which only resolves on a case-insensitive filesystem.
+type Mismatch struct {
+	Depend
+	Line int
+	Path string // as written
+	Want string // as named in the tree
+}
+
+// CaseMismatches returns the include edges of s whose include text differs
+// in case from the names of the files it resolved through. Backslash
+// separators are reported too. Directives that did not resolve are retried
+// with r ignoring case, so that mismatches are found in trees linked without
+// FoldCase; r should then fold case too. A nil r only checks resolved edges.
+func CaseMismatches(ctx context.Context, s *fs.Fs, r Resolver) (ms []Mismatch, errs []error) {
+	linked := make(map[quad.Value]struct{})
+	qs := s.Store.Graph.QuadStore
+	if v := qs.ValueOf(Includes); v != nil {
+		ms, errs = linkedMismatches(ctx, s, v, linked)
+	}
+	if r == nil {
+		return
+	}
+	var files []File
+	if err := s.Store.Select(ctx, &files); err != nil {
+		errs = append(errs, fmt.Errorf("error reading files: %v", err))
+		return
+	}
+	fold := *s
+	fold.FoldCase = true
+	for _, f := range files {
+		for _, d := range f.Includes {
+			if _, ok := linked[d.IRI]; ok {
+				continue
+			}
+			ip, err := d.Include().Path()
+			if err != nil {
+				continue
+			}
+			// Includes not found even ignoring case are for link to report
+			depends, _ := ResolveIncludeFrom(ctx, &fold, f.IRI, r, d.Include())
+			for node := range depends {
+				want, err := caseMismatch(ctx, s, node, ip)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if want != ip {
+					ms = append(ms, Mismatch{
+						Depend: Depend{From: f.IRI, To: node, Directive: d.IRI},
+						Line:   d.Line,
+						Path:   ip,
+						Want:   want,
+					})
+				}
+			}
+		}
+	}
+	return
+}
+
+// linkedMismatches returns the mismatches among the include edges, adding the
+// directive of each edge to linked.
+func linkedMismatches(ctx context.Context, s *fs.Fs, v graph.Value, linked map[quad.Value]struct{}) (ms []Mismatch, errs []error) {
+	qs := s.Store.Graph.QuadStore
+	it := qs.QuadIterator(quad.Predicate, v)
+	defer it.Close()
+	for it.Next(ctx) {
+		q := qs.Quad(it.Result())
+		if q.Label == nil {
+			continue
+		}
+		linked[q.Label] = struct{}{}
+		var d Directive
+		if err := s.Store.Select(ctx, &d, q.Label); err != nil {
+			errs = append(errs, fmt.Errorf("error reading directive %v: %v", q.Label, err))
+			continue
+		}
+		ip, err := d.Include().Path()
+		if err != nil {
+			continue
+		}
+		want, err := caseMismatch(ctx, s, q.Object, ip)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if want != ip {
+			ms = append(ms, Mismatch{
+				Depend: Depend{From: q.Subject, To: q.Object, Directive: q.Label},
+				Line:   d.Line,
+				Path:   ip,
+				Want:   want,
+			})
+		}
+	}
+	if err := it.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("error reading includes: %v", err))
+	}
+	return
+}
+
+// caseMismatch returns include path ip with the case of its trailing names
+// corrected to those of node and its parents. Names before a ".." and names
+// that differ by more than case, as through a symlink, are left as written.
+func caseMismatch(ctx context.Context, s *fs.Fs, node quad.Value, ip string) (string, error) {
+	bases := strings.Split(strings.Replace(ip, "\\", "/", -1), "/")
+	for n := len(bases) - 1; n >= 0 && node != nil; n-- {
+		switch bases[n] {
+		case "", ".":
+			continue
+		case "..":
+			return strings.Join(bases, "/"), nil
+		}
+		var f fs.File
+		if err := s.Store.Select(ctx, &f, node); err != nil {
+			return "", fmt.Errorf("error reading file %v: %v", node, err)
+		}
+		if !strings.EqualFold(bases[n], f.Name) {
+			break
+		}
+		bases[n] = f.Name
+		node = nil
+		if f.Dir != nil {
+			node = f.Dir.IRI
+		}
+	}
+	return strings.Join(bases, "/"), nil
+}
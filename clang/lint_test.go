@@ -0,0 +1,100 @@
+package clang
+
+import (
+	"testing"
+	"context"
+	"fmt"
+)
+
+func TestCaseMismatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		include string
+		files   []string
+		want    string
+	}{
+		{
+			"exact",
+			"#include <Foo.h>",
+			[]string{"c/Foo.h"},
+			"",
+		},
+		{
+			"file",
+			"#include <Foo.h>",
+			[]string{"c/foo.h"},
+			"foo.h",
+		},
+		{
+			"dir",
+			"#include <Sub/foo.h>",
+			[]string{"c/sub/foo.h"},
+			"sub/foo.h",
+		},
+		{
+			"user beside source",
+			"#include \"B.H\"",
+			[]string{"a/b.h", "c/d.h"},
+			"b.h",
+		},
+		{
+			"parent",
+			"#include \"../C/Foo.h\"",
+			[]string{"c/foo.h"},
+			"../c/foo.h",
+		},
+		{
+			"backslash",
+			"#include <sub\\foo.h>",
+			[]string{"c/sub/foo.h"},
+			"sub/foo.h",
+		},
+	}
+	for _, test := range tests {
+		for _, fold := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%v/fold=%v", test.name, fold), func(t *testing.T) {
+				ctx := context.TODO()
+				// Setup
+				s := NewFs(t)
+				s.FoldCase = fold
+				src, err := s.Create(ctx, "a/b.c")
+				if err != nil {
+					t.Skipf("error creating test file: %v", err)
+				}
+				for _, p := range test.files {
+					if _, err := s.Create(ctx, p); err != nil {
+						t.Skipf("error creating test file %v: %v", p, err)
+					}
+				}
+				d := Directive{Text: test.include, Line: 1, Kind: Include(test.include).Kind()}
+				if err := WriteIncludes(s.Store, src, d); err != nil {
+					t.Skipf("error writing includes: %v", err)
+				}
+				p, errs := NewOrderedPath(s, "c")
+				if errs != nil {
+					t.Skipf("error initializing include path: %v", errs)
+				}
+				// Without folding, mismatched includes are left unresolved
+				LinkAll(ctx, s, p)
+				// Test
+				p.FoldCase = true
+				ms, errs := CaseMismatches(ctx, s, p)
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				if test.want == "" {
+					if len(ms) > 0 {
+						t.Fatalf("unexpected mismatches: %+v", ms)
+					}
+					return
+				}
+				if len(ms) != 1 {
+					t.Fatalf("unexpected mismatches: expected 1, got %+v", ms)
+				}
+				if m := ms[0]; m.From != src.IRI || m.Line != 1 || m.Want != test.want {
+					t.Fatalf("unexpected mismatch: expected %v, got %+v", test.want, m)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package clang
+
+import (
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/graph/path"
+	"fmt"
+	"github.com/phyrwork/mobius/fs"
+)
+
+type Resolver interface {
+	Resolve(ctx context.Context, i Include) (quad.Value, error)
+}
+
+// PathResolver resolves includes against every directory of an include path at once.
+// An include found in more than one directory is an error.
+type PathResolver struct {
+	Path *path.Path
+	// FoldCase retries includes not found ignoring case.
+	FoldCase bool
+}
+
+func (r PathResolver) Resolve(ctx context.Context, i Include) (node quad.Value, err error) {
+	if r.Path == nil {
+		err = fmt.Errorf("nil include path")
+		return
+	}
+	ip, err := i.Path()
+	if err != nil {
+		err = fmt.Errorf("error in include %v: %v", i, err)
+		return
+	}
+	nodes, err := r.Path.Follow(fs.Path(ip).StartMorphism()).Iterate(ctx).AllValues(nil)
+	if err == nil && len(nodes) == 0 && r.FoldCase {
+		nodes, err = r.Path.Follow(fs.Path(ip).StartFoldMorphism()).Iterate(ctx).AllValues(nil)
+	}
+	if err != nil {
+		err = fmt.Errorf("error following include path: %v", err)
+		return
+	}
+	switch len(nodes) {
+	case 0:
+		err = fmt.Errorf("%v not resolved", i)
+	case 1:
+		node = nodes[0]
+	default:
+		ids := make([]string, len(nodes))
+		for n, node := range nodes {
+			ids[n] = node.String()
+		}
+		err = fmt.Errorf("%v resolved ambiguously: %v", i, ids)
+	}
+	return
+}
+
+// OrderedPath resolves includes against each directory in turn, as a compiler
+// does, and the first match wins.
+type OrderedPath struct {
+	Dirs []*path.Path
+	// Shadowed, if not nil, is called with the matches in later directories
+	// hidden by the resolved node.
+	Shadowed func(i Include, node quad.Value, shadowed []quad.Value)
+	// FoldCase retries includes not found in any directory ignoring case.
+	FoldCase bool
+}
+
+func IncludeDirs(s *fs.Fs, dirs ...string) (paths []*path.Path, errs []error) {
+	for _, dir := range dirs {
+		node, err := s.Lookup(nil, nil, dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if node == nil {
+			errs = append(errs, fmt.Errorf("include directory %v not found", dir))
+			continue
+		}
+		paths = append(paths, path.StartPath(s.Store.Graph, node))
+	}
+	return
+}
+
+func NewOrderedPath(s *fs.Fs, dirs ...string) (p OrderedPath, errs []error) {
+	p.Dirs, errs = IncludeDirs(s, dirs...)
+	p.FoldCase = s.FoldCase
+	return
+}
+
+func (p OrderedPath) Resolve(ctx context.Context, i Include) (node quad.Value, err error) {
+	ip, err := i.Path()
+	if err != nil {
+		err = fmt.Errorf("error in include %v: %v", i, err)
+		return
+	}
+	node, err = p.resolve(ctx, i, fs.Path(ip).StartMorphism())
+	if err == nil && node == nil && p.FoldCase {
+		node, err = p.resolve(ctx, i, fs.Path(ip).StartFoldMorphism())
+	}
+	if err == nil && node == nil {
+		err = fmt.Errorf("%v not resolved", i)
+	}
+	return
+}
+
+// resolve returns the first match of m in the directories, or nil.
+func (p OrderedPath) resolve(ctx context.Context, i Include, m *path.Path) (node quad.Value, err error) {
+	var shadowed []quad.Value
+	for _, dir := range p.Dirs {
+		var found quad.Value
+		found, err = dir.Follow(m).Iterate(ctx).FirstValue(nil)
+		if err != nil {
+			err = fmt.Errorf("error following include path: %v", err)
+			return
+		}
+		switch {
+		case found == nil:
+			// Not in this directory
+		case node == nil:
+			node = found
+			if p.Shadowed == nil {
+				return
+			}
+		case found != node:
+			shadowed = append(shadowed, found)
+		}
+	}
+	if len(shadowed) > 0 {
+		p.Shadowed(i, node, shadowed)
+	}
+	return
+}
+
+// SearchPath models the separate include directory lists of GCC and Clang.
+// User includes search Quote, Angle, System then After; system includes skip Quote.
+// The directory of the including file is searched by ResolveIncludeFrom.
+type SearchPath struct {
+	Quote  []*path.Path // -iquote
+	Angle  []*path.Path // -I
+	System []*path.Path // -isystem
+	After  []*path.Path // -idirafter
+	// Shadowed, if not nil, is called with the matches in later directories
+	// hidden by the resolved node.
+	Shadowed func(i Include, node quad.Value, shadowed []quad.Value)
+	// FoldCase retries includes not found in any directory ignoring case.
+	FoldCase bool
+}
+
+func (p SearchPath) Dirs(kind int) (dirs []*path.Path) {
+	switch kind {
+	case IncludeUser:
+		dirs = append(dirs, p.Quote...)
+		fallthrough
+	case IncludeSys:
+		dirs = append(dirs, p.Angle...)
+		dirs = append(dirs, p.System...)
+		dirs = append(dirs, p.After...)
+	}
+	return
+}
+
+func (p SearchPath) Resolve(ctx context.Context, i Include) (quad.Value, error) {
+	kind := i.Kind()
+	if kind == IncludeError {
+		return nil, fmt.Errorf("error in include %v: not an include", i)
+	}
+	return OrderedPath{Dirs: p.Dirs(kind), Shadowed: p.Shadowed, FoldCase: p.FoldCase}.Resolve(ctx, i)
+}
+
+// Resolvers resolves includes with the first of its resolvers that does.
+type Resolvers []Resolver
+
+func (rs Resolvers) Resolve(ctx context.Context, i Include) (node quad.Value, err error) {
+	err = fmt.Errorf("%v not resolved", i)
+	for _, r := range rs {
+		if node, err = r.Resolve(ctx, i); err == nil && node != nil {
+			return
+		}
+	}
+	return
+}
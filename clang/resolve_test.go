@@ -0,0 +1,262 @@
+package clang
+
+import (
+	"testing"
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"reflect"
+	"github.com/cayleygraph/cayley/graph/path"
+)
+
+func TestOrderedPath_Resolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  string
+		files    []string
+		path     []string
+		depend   string
+		shadowed []string
+		err      bool
+	}{
+		{
+			"on path",
+			"#include <c.h>",
+			[]string{"a/c.h"},
+			[]string{"a", "b"},
+			"a/c.h",
+			nil,
+			false,
+		},
+		{
+			"later on path",
+			"#include <c.h>",
+			[]string{"b/c.h"},
+			[]string{"a", "b"},
+			"b/c.h",
+			nil,
+			false,
+		},
+		{
+			"first on path",
+			"#include <c.h>",
+			[]string{"a/c.h", "b/c.h"},
+			[]string{"a", "b"},
+			"a/c.h",
+			[]string{"b/c.h"},
+			false,
+		},
+		{
+			"first on path (reversed)",
+			"#include <c.h>",
+			[]string{"a/c.h", "b/c.h"},
+			[]string{"b", "a"},
+			"b/c.h",
+			[]string{"a/c.h"},
+			false,
+		},
+		{
+			"repeated directory",
+			"#include <c.h>",
+			[]string{"a/c.h"},
+			[]string{"a", "a"},
+			"a/c.h",
+			nil,
+			false,
+		},
+		{
+			"not on path",
+			"#include <c.h>",
+			[]string{"c.h", "b/d.h"},
+			[]string{"a", "b"},
+			"",
+			nil,
+			true,
+		},
+		{
+			"no path",
+			"#include <c.h>",
+			[]string{"c.h"},
+			[]string{},
+			"",
+			nil,
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+			// Setup
+			s := NewFs(t)
+			for _, p := range append(test.files, "a", "b") {
+				if node, _ := s.Lookup(ctx, nil, p); node != nil {
+					continue
+				}
+				if _, err := s.Create(ctx, p); err != nil {
+					t.Skipf("error creating test file %v: %v", p, err)
+				}
+			}
+			lookup := func(p string) quad.Value {
+				node, err := s.Lookup(ctx, nil, p)
+				if err != nil {
+					t.Skipf("error looking up test file %v: %v", p, err)
+				}
+				return node
+			}
+			var e quad.Value
+			if test.depend != "" {
+				e = lookup(test.depend)
+			}
+			var es []quad.Value
+			for _, p := range test.shadowed {
+				es = append(es, lookup(p))
+			}
+			p, errs := NewOrderedPath(s, test.path...)
+			if errs != nil {
+				t.Skipf("error initializing include path: %v", errs)
+			}
+			var as []quad.Value
+			p.Shadowed = func(_ Include, _ quad.Value, shadowed []quad.Value) {
+				as = shadowed
+			}
+			// Test
+			a, err := p.Resolve(ctx, Include(test.include))
+			if err != nil != test.err {
+				t.Fatalf("unexpected error status: %v", err)
+			}
+			if a != e {
+				t.Fatalf("unexpected resolution: expected %v, got %v", e, a)
+			}
+			if !reflect.DeepEqual(es, as) {
+				t.Fatalf("unexpected shadowed: expected %v, got %v", es, as)
+			}
+		})
+	}
+}
+
+func TestSearchPath_Resolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		include string
+		files   []string
+		quote   []string
+		angle   []string
+		system  []string
+		after   []string
+		depend  string
+		err     bool
+	}{
+		{
+			"user on quote",
+			"#include \"e.h\"",
+			[]string{"q/e.h", "i/e.h"},
+			[]string{"q"},
+			[]string{"i"},
+			nil,
+			nil,
+			"q/e.h",
+			false,
+		},
+		{
+			"sys skips quote",
+			"#include <e.h>",
+			[]string{"q/e.h", "i/e.h"},
+			[]string{"q"},
+			[]string{"i"},
+			nil,
+			nil,
+			"i/e.h",
+			false,
+		},
+		{
+			"sys only on quote",
+			"#include <e.h>",
+			[]string{"q/e.h"},
+			[]string{"q"},
+			[]string{"i"},
+			nil,
+			nil,
+			"",
+			true,
+		},
+		{
+			"angle before system",
+			"#include <e.h>",
+			[]string{"i/e.h", "s/e.h"},
+			nil,
+			[]string{"i"},
+			[]string{"s"},
+			nil,
+			"i/e.h",
+			false,
+		},
+		{
+			"system before after",
+			"#include <e.h>",
+			[]string{"s/e.h", "d/e.h"},
+			nil,
+			nil,
+			[]string{"s"},
+			[]string{"d"},
+			"s/e.h",
+			false,
+		},
+		{
+			"user falls through to after",
+			"#include \"e.h\"",
+			[]string{"d/e.h"},
+			[]string{"q"},
+			[]string{"i"},
+			[]string{"s"},
+			[]string{"d"},
+			"d/e.h",
+			false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+			// Setup
+			s := NewFs(t)
+			for _, p := range append(test.files, "q", "i", "s", "d") {
+				if node, _ := s.Lookup(ctx, nil, p); node != nil {
+					continue
+				}
+				if _, err := s.Create(ctx, p); err != nil {
+					t.Skipf("error creating test file %v: %v", p, err)
+				}
+			}
+			var e quad.Value
+			if test.depend != "" {
+				node, err := s.Lookup(ctx, nil, test.depend)
+				if err != nil {
+					t.Skipf("error looking up test file %v: %v", test.depend, err)
+				}
+				e = node
+			}
+			var p SearchPath
+			for _, l := range []struct {
+				dst  *[]*path.Path
+				dirs []string
+			}{
+				{&p.Quote, test.quote},
+				{&p.Angle, test.angle},
+				{&p.System, test.system},
+				{&p.After, test.after},
+			} {
+				var errs []error
+				*l.dst, errs = IncludeDirs(s, l.dirs...)
+				if errs != nil {
+					t.Skipf("error initializing include path: %v", errs)
+				}
+			}
+			// Test
+			a, err := p.Resolve(ctx, Include(test.include))
+			if err != nil != test.err {
+				t.Fatalf("unexpected error status: %v", err)
+			}
+			if a != e {
+				t.Fatalf("unexpected resolution: expected %v, got %v", e, a)
+			}
+		})
+	}
+}
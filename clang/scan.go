@@ -0,0 +1,29 @@
+package clang
+
+import (
+	"regexp"
+	"io"
+	"bufio"
+)
+
+var (
+	RegExpIncludeLine = regexp.MustCompile("^\\s*#\\s*include\\s*(<[^>]*>|\"[^\"]*\")")
+)
+
+func ScanIncludes(r io.Reader) (includes []Directive, err error) {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		m := RegExpIncludeLine.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		i := Include("#include " + m[1])
+		includes = append(includes, Directive{
+			Text: string(i),
+			Line: line,
+			Kind: i.Kind(),
+		})
+	}
+	err = sc.Err()
+	return
+}
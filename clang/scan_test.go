@@ -0,0 +1,61 @@
+package clang
+
+import (
+	"testing"
+	"strings"
+	"reflect"
+)
+
+func TestScanIncludes(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		includes []Directive
+	}{
+		{
+			"none",
+			"int main() {}\n",
+			nil,
+		},
+		{
+			"user and sys",
+			"#include <a.h>\n#include \"b.h\"\n",
+			[]Directive{
+				{Text: "#include <a.h>", Line: 1, Kind: IncludeSys},
+				{Text: "#include \"b.h\"", Line: 2, Kind: IncludeUser},
+			},
+		},
+		{
+			"indented",
+			"\n\t#include <a.h>\n  #  include \"b.h\"\n",
+			[]Directive{
+				{Text: "#include <a.h>", Line: 2, Kind: IncludeSys},
+				{Text: "#include \"b.h\"", Line: 3, Kind: IncludeUser},
+			},
+		},
+		{
+			"trailing comment",
+			"#include <a.h> // a\n#include \"b.h\" /* b */\n",
+			[]Directive{
+				{Text: "#include <a.h>", Line: 1, Kind: IncludeSys},
+				{Text: "#include \"b.h\"", Line: 2, Kind: IncludeUser},
+			},
+		},
+		{
+			"not include",
+			"#define A\n// #include <a.h>\n#include A_H\n",
+			nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, err := ScanIncludes(strings.NewReader(test.text))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(test.includes, a) {
+				t.Fatalf("unexpected includes: expected %v, got %v", test.includes, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package clang
+
+import (
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/phyrwork/mobius/fs"
+	"sort"
+	"github.com/cayleygraph/cayley/graph/path"
+	"context"
+	"fmt"
+)
+
+const (
+	ListQuote = iota
+	ListAngle
+	ListSystem
+	ListAfter
+)
+
+type SearchDir struct {
+	IRI   quad.IRI `quad:"@id"`
+	Dir   quad.IRI `quad:"clang:dir"`
+	List  int      `quad:"clang:list,opt"`
+	Index int      `quad:"clang:index,opt"`
+}
+
+// Unit is a translation unit: a source file and the options it is compiled with.
+type Unit struct {
+	rdfType struct{}    `quad:"@type > clang:unit"`
+	IRI     quad.IRI    `quad:"@id"`
+	Source  quad.IRI    `quad:"clang:source"`
+	Dirs    []SearchDir `quad:"clang:searchdir,opt"`
+	Defines []string    `quad:"clang:define,opt"`
+}
+
+func (u *Unit) AddDir(list int, dir quad.IRI) {
+	index := 0
+	for _, d := range u.Dirs {
+		if d.List == list {
+			index++
+		}
+	}
+	u.Dirs = append(u.Dirs, SearchDir{
+		Dir:   dir,
+		List:  list,
+		Index: index,
+	})
+}
+
+func (u Unit) SearchPath(s *fs.Fs) (p SearchPath) {
+	dirs := make([]SearchDir, len(u.Dirs))
+	copy(dirs, u.Dirs)
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].List != dirs[j].List {
+			return dirs[i].List < dirs[j].List
+		}
+		return dirs[i].Index < dirs[j].Index
+	})
+	for _, d := range dirs {
+		dir := path.StartPath(s.Store.Graph, d.Dir)
+		switch d.List {
+		case ListQuote:
+			p.Quote = append(p.Quote, dir)
+		case ListAngle:
+			p.Angle = append(p.Angle, dir)
+		case ListSystem:
+			p.System = append(p.System, dir)
+		case ListAfter:
+			p.After = append(p.After, dir)
+		}
+	}
+	p.FoldCase = s.FoldCase
+	return
+}
+
+func WriteUnit(s *fs.Fs, u Unit) (Unit, error) {
+	if len(u.IRI) == 0 {
+		u.IRI = s.Store.GenerateIRI(nil)
+	}
+	for n := range u.Dirs {
+		if len(u.Dirs[n].IRI) == 0 {
+			u.Dirs[n].IRI = s.Store.GenerateIRI(nil)
+		}
+	}
+	_, err := s.Store.Insert(u)
+	return u, err
+}
+
+// LinkUnit links the includes of a translation unit's source, and of every
+// header it reaches, against the unit's search path.
+func LinkUnit(ctx context.Context, s *fs.Fs, u Unit) (errs []error) {
+	return newUnitLinker(s, nil).link(ctx, u)
+}
+
+// unitLinker links the units of a store. A header reached by several units
+// is linked by the first. A later unit that resolves one of its includes
+// differently is reported as a conflict and the first unit's edges are kept,
+// though the later unit goes on to link the headers it resolved to.
+type unitLinker struct {
+	s       *fs.Fs
+	changes *Changes
+	linked  map[quad.Value]struct{}
+}
+
+func newUnitLinker(s *fs.Fs, changes *Changes) unitLinker {
+	return unitLinker{
+		s:       s,
+		changes: changes,
+		linked:  make(map[quad.Value]struct{}),
+	}
+}
+
+// link links the directives of the files u reaches that are made stale by
+// the changes, or every directive if there are none.
+func (l unitLinker) link(ctx context.Context, u Unit) (errs []error) {
+	s := l.s
+	r := u.SearchPath(s)
+	seen := map[quad.Value]struct{}{u.Source: {}}
+	queue := []quad.Value{u.Source}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		var f File
+		if err := s.Store.Select(ctx, &f, node); err != nil {
+			errs = append(errs, fmt.Errorf("error reading file %v: %v", node, err))
+			continue
+		}
+		_, linked := l.linked[node]
+		l.linked[node] = struct{}{}
+		for _, d := range f.Includes {
+			var (
+				nodes []quad.Value
+				derrs []error
+			)
+			stale := true
+			if l.changes != nil {
+				var err error
+				if stale, err = l.changes.Stale(ctx, s.Store, f, d); err != nil {
+					errs = append(errs, fmt.Errorf("%v: line %v: error reading depends: %v", f.Name, d.Line, err))
+					continue
+				}
+			}
+			switch {
+			case !stale:
+				// Follow the edges kept
+				var err error
+				if nodes, err = DirectiveDepends(ctx, s.Store, d.IRI); err != nil {
+					derrs = append(derrs, fmt.Errorf("line %v: error reading depends: %v", d.Line, err))
+				}
+			case linked:
+				// Errors resolving were reported by the earlier unit
+				nodes, _ = resolveDirective(ctx, s, r, f, d)
+				kept, err := DirectiveDepends(ctx, s.Store, d.IRI)
+				if err != nil {
+					derrs = append(derrs, fmt.Errorf("line %v: error reading depends: %v", d.Line, err))
+				} else if !sameValues(nodes, kept) {
+					derrs = append(derrs, fmt.Errorf("line %v: %v resolved to %v by unit of %v, but to %v by an earlier unit", d.Line, d.Text, nodes, u.Source, kept))
+				}
+			default:
+				nodes, derrs = LinkDirective(ctx, s, r, f, d)
+			}
+			for _, err := range derrs {
+				errs = append(errs, fmt.Errorf("%v: %v", f.Name, err))
+			}
+			for _, node := range nodes {
+				if _, ok := seen[node]; ok {
+					continue
+				}
+				seen[node] = struct{}{}
+				queue = append(queue, node)
+			}
+		}
+	}
+	return
+}
+
+// sameValues reports whether a and b hold the same values, in any order.
+func sameValues(a, b []quad.Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[quad.Value]struct{}, len(a))
+	for _, v := range a {
+		set[v] = struct{}{}
+	}
+	for _, v := range b {
+		if _, ok := set[v]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// LinkUnits links every unit in the store, in order of source path. A header
+// reached by several units is linked by the first, and conflicting
+// resolutions by later units are returned as errors.
+func LinkUnits(ctx context.Context, s *fs.Fs) (errs []error) {
+	return RelinkUnits(ctx, s, nil)
+}
+
+// RelinkUnits links the units like LinkUnits, but only relinks the
+// directives made stale by changes, or every directive if changes is nil.
+func RelinkUnits(ctx context.Context, s *fs.Fs, changes *Changes) (errs []error) {
+	var units []Unit
+	if err := s.Store.Select(ctx, &units); err != nil {
+		errs = append(errs, fmt.Errorf("error reading units: %v", err))
+		return
+	}
+	sources := make([]quad.Value, len(units))
+	for n, u := range units {
+		sources[n] = u.Source
+	}
+	paths, err := s.PathsOf(ctx, sources...)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error reading unit sources: %v", err))
+		return
+	}
+	order := make(map[quad.IRI]string, len(units))
+	for n, u := range units {
+		order[u.IRI] = paths[n]
+	}
+	sort.Slice(units, func(i, j int) bool {
+		return order[units[i].IRI] < order[units[j].IRI]
+	})
+	l := newUnitLinker(s, changes)
+	for _, u := range units {
+		errs = append(errs, l.link(ctx, u)...)
+	}
+	return
+}
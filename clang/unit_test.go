@@ -0,0 +1,182 @@
+package clang
+
+import (
+	"testing"
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/graph/path"
+)
+
+func TestLinkUnits(t *testing.T) {
+	ctx := context.TODO()
+	// Setup
+	s := NewFs(t)
+	includes := map[string][]Directive{
+		"src/a.c": {{Text: "#include <b.h>", Line: 1, Kind: IncludeSys}},
+		"src/b.c": {{Text: "#include <b.h>", Line: 1, Kind: IncludeSys}},
+		"x/b.h":   {{Text: "#include <c.h>", Line: 1, Kind: IncludeSys}},
+		"y/b.h":   {{Text: "#include <c.h>", Line: 1, Kind: IncludeSys}},
+		// Reached by both units
+		"y/c.h": {{Text: "#include <e.h>", Line: 1, Kind: IncludeSys}},
+		"y/e.h": {},
+	}
+	files := make(map[string]quad.IRI)
+	for p, d := range includes {
+		f, err := s.Create(ctx, p)
+		if err != nil {
+			t.Skipf("error creating test file %v: %v", p, err)
+		}
+		if err := WriteIncludes(s.Store, f, d...); err != nil {
+			t.Skipf("error writing includes: %v", err)
+		}
+		files[p] = f.IRI
+	}
+	dirs := make(map[string]quad.IRI)
+	for _, p := range []string{"x", "y"} {
+		var f File
+		if _, err := s.Lookup(ctx, &f, p); err != nil {
+			t.Skipf("error looking up test file %v: %v", p, err)
+		}
+		dirs[p] = f.IRI
+	}
+	units := []struct {
+		src  string
+		dirs []string
+	}{
+		{"src/a.c", []string{"x", "y"}},
+		{"src/b.c", []string{"y", "x"}},
+	}
+	for _, u := range units {
+		unit := Unit{Source: files[u.src]}
+		for _, d := range u.dirs {
+			unit.AddDir(ListAngle, dirs[d])
+		}
+		if _, err := WriteUnit(s, unit); err != nil {
+			t.Skipf("error writing unit: %v", err)
+		}
+	}
+	// Test
+	if errs := LinkUnits(ctx, s); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	e := map[string][]string{
+		"src/a.c": {"x/b.h"},
+		"src/b.c": {"y/b.h"},
+		"x/b.h":   {"y/c.h"},
+		"y/b.h":   {"y/c.h"},
+		"y/c.h":   {"y/e.h"},
+	}
+	for src, deps := range e {
+		nodes, err := path.StartPath(s.Store.Graph, files[src]).Follow(IncludesMorphism).Iterate(ctx).AllValues(nil)
+		if err != nil {
+			t.Fatalf("error following includes: %v", err)
+		}
+		if len(nodes) != len(deps) {
+			t.Fatalf("unexpected includes of %v: expected %v, got %v", src, deps, nodes)
+		}
+		for n, dep := range deps {
+			if nodes[n] != files[dep] {
+				t.Fatalf("unexpected includes of %v: expected %v, got %v", src, deps, nodes)
+			}
+		}
+	}
+}
+
+func TestLinkUnits_Conflict(t *testing.T) {
+	ctx := context.TODO()
+	// Setup
+	s := NewFs(t)
+	includes := map[string][]Directive{
+		"src/a.c": {{Text: "#include <s.h>", Line: 1, Kind: IncludeSys}},
+		"src/b.c": {{Text: "#include <s.h>", Line: 1, Kind: IncludeSys}},
+		// Reached by both units, which resolve its include differently
+		"x/s.h": {{Text: "#include <d.h>", Line: 1, Kind: IncludeSys}},
+		"x/d.h": {},
+		"y/d.h": {},
+	}
+	files := make(map[string]quad.IRI)
+	for p, d := range includes {
+		f, err := s.Create(ctx, p)
+		if err != nil {
+			t.Skipf("error creating test file %v: %v", p, err)
+		}
+		if err := WriteIncludes(s.Store, f, d...); err != nil {
+			t.Skipf("error writing includes: %v", err)
+		}
+		files[p] = f.IRI
+	}
+	dirs := make(map[string]quad.IRI)
+	for _, p := range []string{"x", "y"} {
+		var f File
+		if _, err := s.Lookup(ctx, &f, p); err != nil {
+			t.Skipf("error looking up test file %v: %v", p, err)
+		}
+		dirs[p] = f.IRI
+	}
+	units := []struct {
+		src  string
+		dirs []string
+	}{
+		{"src/a.c", []string{"x", "y"}},
+		{"src/b.c", []string{"y", "x"}},
+	}
+	for _, u := range units {
+		unit := Unit{Source: files[u.src]}
+		for _, d := range u.dirs {
+			unit.AddDir(ListAngle, dirs[d])
+		}
+		if _, err := WriteUnit(s, unit); err != nil {
+			t.Skipf("error writing unit: %v", err)
+		}
+	}
+	// Test
+	if errs := LinkUnits(ctx, s); len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	// Units are linked in order of source path
+	nodes, err := path.StartPath(s.Store.Graph, files["x/s.h"]).Follow(IncludesMorphism).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		t.Fatalf("error following includes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0] != files["x/d.h"] {
+		t.Fatalf("unexpected includes of x/s.h: %v", nodes)
+	}
+}
+
+func TestLinkUnits_Unresolved(t *testing.T) {
+	ctx := context.TODO()
+	// Setup
+	s := NewFs(t)
+	includes := map[string][]Directive{
+		"src/a.c": {{Text: "#include <s.h>", Line: 1, Kind: IncludeSys}},
+		"src/b.c": {{Text: "#include <s.h>", Line: 1, Kind: IncludeSys}},
+		// Reached by both units, neither of which resolves its include
+		"x/s.h": {{Text: "#include <d.h>", Line: 1, Kind: IncludeSys}},
+	}
+	files := make(map[string]quad.IRI)
+	for p, d := range includes {
+		f, err := s.Create(ctx, p)
+		if err != nil {
+			t.Skipf("error creating test file %v: %v", p, err)
+		}
+		if err := WriteIncludes(s.Store, f, d...); err != nil {
+			t.Skipf("error writing includes: %v", err)
+		}
+		files[p] = f.IRI
+	}
+	var x File
+	if _, err := s.Lookup(ctx, &x, "x"); err != nil {
+		t.Skipf("error looking up test file x: %v", err)
+	}
+	for _, src := range []string{"src/a.c", "src/b.c"} {
+		unit := Unit{Source: files[src]}
+		unit.AddDir(ListAngle, x.IRI)
+		if _, err := WriteUnit(s, unit); err != nil {
+			t.Skipf("error writing unit: %v", err)
+		}
+	}
+	// Test
+	if errs := LinkUnits(ctx, s); len(errs) != 1 {
+		t.Fatalf("unexpected errors: expected one, got %v", errs)
+	}
+}
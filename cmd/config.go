@@ -0,0 +1,197 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/viper"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"github.com/phyrwork/mobius/filter"
+	"fmt"
+	"sort"
+	"github.com/spf13/pflag"
+)
+
+// config is the workspace configuration. It is read from .mobius.yaml in the
+// working directory (or $HOME) and overridden by flags. Relative paths in the
+// config file are relative to its directory.
+//
+//   root: .
+//   roots:               # other trees indexed into the same store
+//     sdk: /opt/sdk      # include directories can then name @sdk/include
+//   include:
+//     quote: [src]
+//     angle: [include]
+//     system: [third_party/include]
+//     after: []
+//     compdb: build/compile_commands.json
+//     foldcase: false    # resolve includes ignoring case (see mobius lint)
+//   import:
+//     include: []        # regexps; import only matching paths
+//     exclude: ['^\.git'] # regexps; do not import matching paths
+//   store:
+//     path: .mobius.db
+//     backend: bolt
+//   language:
+//     sources: [c, h]    # extensions scanned for #include directives
+//     units: [c]         # extensions of translation units
+type config struct {
+	Root    string            `mapstructure:"root"`
+	Roots   map[string]string `mapstructure:"roots"`
+	Include struct {
+		Quote    []string `mapstructure:"quote"`
+		Angle    []string `mapstructure:"angle"`
+		System   []string `mapstructure:"system"`
+		After    []string `mapstructure:"after"`
+		CompDb   string   `mapstructure:"compdb"`
+		Shadowed bool     `mapstructure:"shadowed"`
+		FoldCase bool     `mapstructure:"foldcase"`
+	} `mapstructure:"include"`
+	Import struct {
+		Include []string `mapstructure:"include"`
+		Exclude []string `mapstructure:"exclude"`
+	} `mapstructure:"import"`
+	Store struct {
+		Path    string `mapstructure:"path"`
+		Backend string `mapstructure:"backend"`
+	} `mapstructure:"store"`
+	Language struct {
+		Sources []string `mapstructure:"sources"`
+		Units   []string `mapstructure:"units"`
+	} `mapstructure:"language"`
+}
+
+func init() {
+	viper.SetDefault("root", ".")
+	viper.SetDefault("language.sources", []string{"c", "cc", "cpp", "cxx", "h", "hh", "hpp", "hxx", "inc"})
+	viper.SetDefault("language.units", []string{"c", "cc", "cpp", "cxx"})
+}
+
+func loadConfig() (c config, err error) {
+	if err = viper.Unmarshal(&c); err != nil {
+		err = fmt.Errorf("error reading config: %v", err)
+		return
+	}
+	// Only paths read from the config file are relative to it; flags and
+	// defaults are relative to the working directory.
+	if used := viper.ConfigFileUsed(); used != "" {
+		file := viper.New()
+		file.SetConfigFile(used)
+		if err = file.ReadInConfig(); err != nil {
+			err = fmt.Errorf("error reading config: %v", err)
+			return
+		}
+		dir := filepath.Dir(used)
+		for key, p := range map[string]*string{
+			"root":           &c.Root,
+			"include.compdb": &c.Include.CompDb,
+			"store.path":     &c.Store.Path,
+		} {
+			if *p != "" && !filepath.IsAbs(*p) && inConfig(file, key) {
+				*p = filepath.Join(dir, *p)
+			}
+		}
+		for name, p := range c.Roots {
+			if !filepath.IsAbs(p) && inConfig(file, "roots."+name) {
+				c.Roots[name] = filepath.Join(dir, p)
+			}
+		}
+	}
+	return
+}
+
+// flags are the flags bound to config keys, so that values given on the
+// command line can be told from those read from the config file.
+var flags = make(map[string]*pflag.Flag)
+
+// bindFlag binds a config key to a flag.
+func bindFlag(key string, flag *pflag.Flag) {
+	viper.BindPFlag(key, flag)
+	flags[key] = flag
+}
+
+// inConfig reports whether the value of key is that read from the config
+// file, not overridden by a flag.
+func inConfig(file *viper.Viper, key string) bool {
+	if flag, ok := flags[key]; ok && flag.Changed {
+		return false
+	}
+	return file.IsSet(key)
+}
+
+// extensions returns a regexp matching paths with any of exts.
+func extensions(exts []string) *regexp.Regexp {
+	quoted := make([]string, len(exts))
+	for n, ext := range exts {
+		quoted[n] = regexp.QuoteMeta(strings.TrimPrefix(ext, "."))
+	}
+	return regexp.MustCompile("\\.(" + strings.Join(quoted, "|") + ")$")
+}
+
+// importFilter returns the filter for imported paths, or nil to import everything.
+func (c config) importFilter() (filter.Filter, error) {
+	var list []filter.Filter
+	for _, l := range []struct {
+		patterns []string
+		wrap     func([]filter.Filter) filter.Filter
+	}{
+		{c.Import.Include, func(list []filter.Filter) filter.Filter { return filter.OrFilter{List: list} }},
+		{c.Import.Exclude, func(list []filter.Filter) filter.Filter { return filter.NorFilter{List: list} }},
+	} {
+		if len(l.patterns) == 0 {
+			continue
+		}
+		filts := make([]filter.Filter, len(l.patterns))
+		for n, pattern := range l.patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("error in import pattern %v: %v", pattern, err)
+			}
+			filts[n] = filter.RegexpFilter{Regexp: re}
+		}
+		list = append(list, l.wrap(filts))
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	return filter.AndFilter{List: list}, nil
+}
+
+// roots returns the names of the roots to index, the default root first.
+func (c config) roots() []string {
+	names := []string{""}
+	for name := range c.Roots {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// rootDirs returns the directories of the roots by name.
+func (c config) rootDirs() map[string]string {
+	dirs := make(map[string]string)
+	for _, name := range c.roots() {
+		dirs[name] = c.rootDir(name)
+	}
+	return dirs
+}
+
+// rootDir returns the directory of the root with name.
+func (c config) rootDir(name string) string {
+	if name == "" {
+		return c.Root
+	}
+	return c.Roots[name]
+}
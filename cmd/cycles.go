@@ -0,0 +1,107 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"log"
+	"context"
+	"github.com/phyrwork/mobius/clang"
+	"fmt"
+	"strings"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/phyrwork/mobius/fs"
+)
+
+var (
+	cyclesScc bool
+)
+
+// cyclesCmd represents the cycles command
+var cyclesCmd = &cobra.Command{
+	Use:   "cycles",
+	Short: "List include cycles",
+	Long: `List every include cycle in the source tree as a chain of files, each
+with the lines of the #include directives that lead to the next.
+
+With --scc, list the strongly connected components of the include graph
+instead; every file in a component is reachable from every other.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		s, f, err := openFs(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		if cyclesScc {
+			components, err := clang.Components(ctx, f)
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, c := range components {
+				paths, err := f.PathsOf(ctx, c...)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println(strings.Join(paths, " "))
+			}
+			return
+		}
+		cycles, err := clang.Cycles(ctx, f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, c := range cycles {
+			s, err := formatCycle(ctx, f, c)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(s)
+		}
+	},
+}
+
+// formatCycle formats a cycle as path:lines -> path:lines -> ... -> path.
+func formatCycle(ctx context.Context, f *fs.Fs, c []quad.Value) (string, error) {
+	links := make([]string, len(c))
+	for n, node := range c {
+		p, err := f.Path(ctx, node)
+		if err != nil {
+			return "", err
+		}
+		links[n] = p
+		if n == len(c)-1 {
+			break
+		}
+		lines, err := clang.Lines(ctx, f, node, c[n+1])
+		if err != nil {
+			return "", err
+		}
+		s := make([]string, len(lines))
+		for i, line := range lines {
+			s[i] = fmt.Sprint(line)
+		}
+		if len(s) > 0 {
+			links[n] += ":" + strings.Join(s, ",")
+		}
+	}
+	return strings.Join(links, " -> "), nil
+}
+
+func init() {
+	RootCmd.AddCommand(cyclesCmd)
+
+	cyclesCmd.Flags().BoolVar(&cyclesScc, "scc", false, "list strongly connected components instead of cycles")
+}
@@ -0,0 +1,69 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"log"
+	"context"
+	"github.com/phyrwork/mobius/clang"
+	"fmt"
+)
+
+var (
+	depsMaxDepth int
+)
+
+// depsCmd represents the deps command
+var depsCmd = &cobra.Command{
+	Use:   "deps <path>",
+	Short: "List the files a file includes, directly or indirectly",
+	Long: `List every file reached by following #include directives from the file at
+path, with the number of includes between them.
+
+Paths are relative to the source root.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		s, f, err := openFs(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		node, err := f.Lookup(ctx, nil, args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if node == nil {
+			log.Fatalf("file %v not found", args[0])
+		}
+		nodes, err := clang.Closure(ctx, f, node, depsMaxDepth)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, r := range nodes {
+			p, err := f.Path(ctx, r.Node)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%v\t%v\n", r.Depth, p)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(depsCmd)
+
+	depsCmd.Flags().IntVar(&depsMaxDepth, "max-depth", 0, "maximum include depth to follow (0 for no limit)")
+}
@@ -0,0 +1,173 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/afero"
+	"log"
+	ext "github.com/phyrwork/mobius/external/fs"
+	"github.com/phyrwork/mobius/fs"
+	"github.com/phyrwork/mobius/store"
+	extclang "github.com/phyrwork/mobius/external/clang"
+	"github.com/phyrwork/mobius/filter"
+	"github.com/spf13/viper"
+	"github.com/phyrwork/mobius/clang"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/phyrwork/mobius/external/compdb"
+	"path/filepath"
+	"context"
+	"fmt"
+)
+
+// addIndexFlags adds the flags used by index to a command and binds them to the config.
+func addIndexFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("iquote", nil, "quote include search directory (relative to root)")
+	cmd.Flags().StringSliceP("include", "I", nil, "include search directory (relative to root)")
+	cmd.Flags().StringSlice("isystem", nil, "system include search directory (relative to root)")
+	cmd.Flags().StringSlice("idirafter", nil, "include search directory searched last (relative to root)")
+	cmd.Flags().String("compdb", "", "compilation database (compile_commands.json) to take include search paths from")
+	cmd.Flags().Bool("shadowed", false, "report includes shadowed by earlier include directories")
+	cmd.Flags().Bool("fold-case", false, "resolve includes ignoring case, as on Windows and macOS")
+	// Several commands share the keys, so bind only those of the command run.
+	cmd.PreRun = func(cmd *cobra.Command, args []string) {
+		for key, flag := range map[string]string{
+			"include.quote":    "iquote",
+			"include.angle":    "include",
+			"include.system":   "isystem",
+			"include.after":    "idirafter",
+			"include.compdb":   "compdb",
+			"include.shadowed": "shadowed",
+			"include.foldcase": "fold-case",
+		} {
+			bindFlag(key, cmd.Flags().Lookup(flag))
+		}
+	}
+}
+
+// index imports the source trees of c into s and links their includes.
+func index(ctx context.Context, s *store.Store, c config) (*fs.Fs, error) {
+	var def *fs.Fs
+	for _, name := range c.roots() {
+		f, err := importTree(ctx, s, c, name)
+		if err != nil {
+			return nil, err
+		}
+		if def == nil {
+			def = f
+		}
+	}
+	for _, err := range def.ResolveLinks(ctx) {
+		log.Print(err)
+	}
+	if c.Include.CompDb != "" {
+		root, err := filepath.Abs(c.Root)
+		if err != nil {
+			return nil, err
+		}
+		db := compdb.NewImporter(afero.NewOsFs(), root, c.Include.CompDb)
+		for _, err := range db.Import(ctx, def) {
+			log.Print(err)
+		}
+	}
+	def.FoldCase = c.Include.FoldCase
+	link(ctx, def, c, nil)
+	return def, nil
+}
+
+// importTree imports and scans the tree of the root with name.
+func importTree(ctx context.Context, s *store.Store, c config, name string) (*fs.Fs, error) {
+	dir := c.rootDir(name)
+	io := afero.NewOsFs()
+	im := ext.NewImporter(io, dir)
+	filt, err := c.importFilter()
+	if err != nil {
+		return nil, err
+	}
+	im.Filter = filt
+	im.Roots = c.rootDirs()
+	f, err := fs.NewNamedFs(ctx, s, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := im.Import(ctx, f); err != nil {
+		return nil, fmt.Errorf("error importing %v: %v", dir, err)
+	}
+	sc := extclang.NewScanner(io, dir)
+	sc.Filter = filter.RegexpFilter{Regexp: extensions(c.Language.Sources)}
+	if err := sc.Scan(ctx, f); err != nil {
+		return nil, fmt.Errorf("error scanning %v: %v", dir, err)
+	}
+	return f, nil
+}
+
+// link resolves the includes in f, using the units imported from the
+// compilation database if there is one. If changes is not nil, only the
+// includes they may have affected are relinked.
+func link(ctx context.Context, f *fs.Fs, c config, changes *clang.Changes) {
+	if c.Include.CompDb != "" {
+		for _, err := range clang.RelinkUnits(ctx, f, changes) {
+			log.Print(err)
+		}
+		return
+	}
+	p := searchPath(f, c)
+	if c.Include.Shadowed {
+		p.Shadowed = func(i clang.Include, node quad.Value, shadowed []quad.Value) {
+			log.Printf("%v resolved to %v, shadowing %v", i, node, shadowed)
+		}
+	}
+	for _, err := range clang.RelinkAll(ctx, f, p, changes) {
+		log.Print(err)
+	}
+}
+
+// searchPath returns the include search path of c in f.
+func searchPath(f *fs.Fs, c config) clang.SearchPath {
+	p := clang.SearchPath{FoldCase: f.FoldCase}
+	for _, l := range []struct {
+		dst  *[]*path.Path
+		dirs []string
+	}{
+		{&p.Quote, c.Include.Quote},
+		{&p.Angle, c.Include.Angle},
+		{&p.System, c.Include.System},
+		{&p.After, c.Include.After},
+	} {
+		var errs []error
+		*l.dst, errs = clang.IncludeDirs(f, l.dirs...)
+		for _, err := range errs {
+			log.Print(err)
+		}
+	}
+	return p
+}
+
+// resolver returns the resolver link uses for f. With a compilation database
+// an include is resolved by the first unit's search path that resolves it.
+func resolver(ctx context.Context, f *fs.Fs, c config) (clang.Resolver, error) {
+	if c.Include.CompDb == "" {
+		return searchPath(f, c), nil
+	}
+	var units []clang.Unit
+	if err := f.Store.Select(ctx, &units); err != nil {
+		return nil, fmt.Errorf("error reading units: %v", err)
+	}
+	rs := make(clang.Resolvers, len(units))
+	for n, u := range units {
+		rs[n] = u.SearchPath(f)
+	}
+	return rs, nil
+}
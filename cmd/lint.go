@@ -0,0 +1,89 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"log"
+	"context"
+	"github.com/phyrwork/mobius/clang"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report includes that only resolve ignoring case",
+	Long: `List every #include directive whose path differs in case from the file it
+resolved to, e.g. #include "Foo.h" for foo.h. These only resolve on
+case-insensitive filesystems. Includes left unresolved when the tree was
+indexed are retried ignoring case, so they are found whether or not it was
+indexed with --fold-case (or include.foldcase).
+
+Exits with status 1 if any are found.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		c, err := loadConfig()
+		if err != nil {
+			log.Fatal(err)
+		}
+		s, f, err := openFs(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		// Includes left unresolved by a case-sensitive link are retried
+		// ignoring case
+		fold := *f
+		fold.FoldCase = true
+		r, err := resolver(ctx, &fold, c)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ms, errs := clang.CaseMismatches(ctx, f, r)
+		for _, err := range errs {
+			log.Print(err)
+		}
+		paths := make([]string, len(ms))
+		for n, m := range ms {
+			if paths[n], err = f.Path(ctx, m.From); err != nil {
+				log.Fatal(err)
+			}
+		}
+		order := make([]int, len(ms))
+		for n := range order {
+			order[n] = n
+		}
+		sort.Slice(order, func(i, j int) bool {
+			a, b := order[i], order[j]
+			if paths[a] != paths[b] {
+				return paths[a] < paths[b]
+			}
+			return ms[a].Line < ms[b].Line
+		})
+		for _, n := range order {
+			fmt.Printf("%v:%v: %q should be %q\n", paths[n], ms[n].Line, ms[n].Path, ms[n].Want)
+		}
+		if len(ms) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(lintCmd)
+}
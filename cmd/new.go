@@ -16,17 +16,14 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
-	"github.com/spf13/afero"
 	"log"
-	ext "github.com/phyrwork/mobius/external/fs"
-	"github.com/phyrwork/mobius/fs"
-	"github.com/cayleygraph/cayley"
+	"context"
 	"github.com/phyrwork/mobius/store"
 )
 
 // newCmd represents the new command
 var newCmd = &cobra.Command{
-	Use:   "new",
+	Use:   "new [root]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -34,27 +31,31 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Args: cobra.ExactArgs(1),
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		dir := args[0]
-		io := afero.NewOsFs()
-		im := ext.NewImporter(io, dir)
-		qs, err := cayley.NewMemoryGraph()
+		c, err := loadConfig()
 		if err != nil {
 			log.Fatal(err)
 		}
-		s := store.New(qs)
-		f, err := fs.NewFs(nil, s)
+		if len(args) > 0 {
+			c.Root = args[0]
+		}
+		s, err := store.Init(c.storeConfig())
 		if err != nil {
 			log.Fatal(err)
 		}
-		im.Import(nil, f)
+		defer s.Close()
+		if _, err := index(context.Background(), s, c); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(newCmd)
 
+	addIndexFlags(newCmd)
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
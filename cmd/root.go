@@ -20,6 +20,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/phyrwork/mobius/store"
 )
 
 var cfgFile string
@@ -55,7 +56,11 @@ func init() {
 	// Cobra supports Persistent Flags, which, if defined here,
 	// will be global for your application.
 
-	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.mobius.yaml)")
+	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./.mobius.yaml or $HOME/.mobius.yaml)")
+	RootCmd.PersistentFlags().String("db", ".mobius.db", "graph database path")
+	RootCmd.PersistentFlags().String("backend", store.DefaultBackend, "graph database backend (bolt, leveldb or memstore)")
+	bindFlag("store.path", RootCmd.PersistentFlags().Lookup("db"))
+	bindFlag("store.backend", RootCmd.PersistentFlags().Lookup("backend"))
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	RootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
@@ -65,11 +70,12 @@ func init() {
 func initConfig() {
 	if cfgFile != "" { // enable ability to specify config file via flag
 		viper.SetConfigFile(cfgFile)
+	} else {
+		viper.SetConfigName(".mobius") // name of config file (without extension)
+		viper.AddConfigPath(".")      // adding workspace as first search path
+		viper.AddConfigPath("$HOME")  // then home directory
 	}
-
-	viper.SetConfigName(".mobius") // name of config file (without extension)
-	viper.AddConfigPath("$HOME")  // adding home directory as first search path
-	viper.AutomaticEnv()          // read in environment variables that match
+	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
@@ -0,0 +1,50 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"github.com/phyrwork/mobius/store"
+	"github.com/phyrwork/mobius/fs"
+	"context"
+	"fmt"
+)
+
+func (c config) storeConfig() store.Config {
+	return store.Config{
+		Backend: c.Store.Backend,
+		Path:    c.Store.Path,
+	}
+}
+
+// openFs opens the graph database written by the new command.
+func openFs(ctx context.Context) (*store.Store, *fs.Fs, error) {
+	c, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := store.Open(c.storeConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	var root fs.File
+	v, err := fs.FindRoot(ctx, s, &root)
+	if err == nil && v == nil {
+		err = fmt.Errorf("%v not indexed (see mobius new)", c.Store.Path)
+	}
+	if err != nil {
+		s.Close()
+		return nil, nil, err
+	}
+	return s, &fs.Fs{Store: s, Root: root, FoldCase: c.Include.FoldCase}, nil
+}
@@ -0,0 +1,132 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"log"
+	"context"
+	"github.com/spf13/afero"
+	ext "github.com/phyrwork/mobius/external/fs"
+	extclang "github.com/phyrwork/mobius/external/clang"
+	"github.com/phyrwork/mobius/filter"
+	"fmt"
+	"github.com/phyrwork/mobius/store"
+	"github.com/phyrwork/mobius/fs"
+	"path/filepath"
+	"github.com/phyrwork/mobius/clang"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Update the graph with files changed since it was indexed",
+	Long: `Walk the source roots, adding files that are not in the graph and removing
+files that no longer exist. Files already in the graph keep their nodes and
+include edges unless their content changed; new and modified files are
+scanned, and only includes that may now resolve differently are relinked.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		c, err := loadConfig()
+		if err != nil {
+			log.Fatal(err)
+		}
+		s, f, err := openFs(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer s.Close()
+		before, err := f.LinkTargets(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		changes := clang.NewChanges()
+		for _, name := range c.roots() {
+			if err := syncTree(ctx, s, c, name, changes); err != nil {
+				log.Fatal(err)
+			}
+		}
+		for _, err := range f.ResolveLinks(ctx) {
+			log.Print(err)
+		}
+		after, err := f.LinkTargets(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		changes.AddLinks(before, after)
+		link(ctx, f, c, &changes)
+	},
+}
+
+// syncTree syncs and rescans the tree of the root with name, printing changes
+// and adding them to changes.
+func syncTree(ctx context.Context, s *store.Store, c config, name string, changes clang.Changes) error {
+	dir := c.rootDir(name)
+	f, err := fs.NewNamedFs(ctx, s, name)
+	if err != nil {
+		return err
+	}
+	io := afero.NewOsFs()
+	im := ext.NewImporter(io, dir)
+	if im.Filter, err = c.importFilter(); err != nil {
+		return err
+	}
+	im.Roots = c.rootDirs()
+	created, modified, removed, err := im.Sync(ctx, f)
+	if err != nil {
+		return fmt.Errorf("error syncing %v: %v", dir, err)
+	}
+	for _, l := range []struct {
+		mark  string
+		paths []string
+	}{
+		{"+", created},
+		{"M", modified},
+		{"-", removed},
+	} {
+		for _, p := range l.paths {
+			if name != "" {
+				p = filepath.Join(fs.RootPrefix+name, p)
+			}
+			fmt.Printf("%v\t%v\n", l.mark, p)
+		}
+	}
+	for n, p := range append(created, modified...) {
+		node, err := f.LookupLink(ctx, nil, fs.Literal(p))
+		if err != nil {
+			return fmt.Errorf("error looking up graph file %v: %v", p, err)
+		}
+		if node == nil {
+			continue
+		}
+		if n < len(created) {
+			changes.AddCreated(node, p)
+		} else {
+			changes.AddModified(node)
+		}
+	}
+	sc := extclang.NewScanner(io, dir)
+	sc.Filter = filter.RegexpFilter{Regexp: extensions(c.Language.Sources)}
+	if err := sc.ScanPaths(ctx, f, append(created, modified...)...); err != nil {
+		return fmt.Errorf("error scanning %v: %v", dir, err)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(syncCmd)
+
+	addIndexFlags(syncCmd)
+}
@@ -0,0 +1,103 @@
+package clang
+
+import (
+	"context"
+	"github.com/spf13/afero"
+	"github.com/phyrwork/mobius/filter"
+	"github.com/phyrwork/mobius/fs"
+	"github.com/phyrwork/mobius/clang"
+	ext "github.com/phyrwork/mobius/external/fs"
+	"os"
+	"fmt"
+)
+
+type Scanner struct {
+	io afero.Fs
+	Filter filter.Filter
+}
+
+func NewScanner(io afero.Fs, root string) Scanner {
+	return Scanner{io: ext.RootFs(io, root)}
+}
+
+func (sc Scanner) scan(ctx context.Context, dst *fs.Fs, path string) error {
+	var f clang.File
+	node, err := dst.LookupLink(ctx, &f, fs.Literal(path))
+	if err != nil {
+		return fmt.Errorf("error looking up graph file %v: %v", path, err)
+	}
+	if node == nil {
+		// Not imported
+		return nil
+	}
+	if f.Kind == fs.KindSymlink {
+		// Scanned at the target
+		return nil
+	}
+	r, err := sc.io.Open(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	includes, err := clang.ScanIncludes(r)
+	if err != nil {
+		return fmt.Errorf("error scanning %v: %v", path, err)
+	}
+	// Replace includes from an earlier scan
+	if err := clang.ClearIncludes(ctx, dst.Store, f); err != nil {
+		return fmt.Errorf("error clearing includes for %v: %v", path, err)
+	}
+	if err := clang.WriteIncludes(dst.Store, f.File, includes...); err != nil {
+		return fmt.Errorf("error writing includes for %v: %v", path, err)
+	}
+	return nil
+}
+
+func (sc Scanner) Scan(ctx context.Context, dst *fs.Fs) error {
+	return afero.Walk(sc.io, ".", func (path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		// Filter
+		if sc.Filter != nil {
+			pass, err := sc.Filter.Filter(path)
+			if err != nil {
+				return err
+			}
+			if !pass {
+				return nil
+			}
+		}
+		return sc.scan(ctx, dst, path)
+	})
+}
+
+// ScanPaths scans only the given files, e.g. those created or modified by a
+// sync.
+func (sc Scanner) ScanPaths(ctx context.Context, dst *fs.Fs, paths ...string) error {
+	for _, path := range paths {
+		info, err := sc.io.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+		if sc.Filter != nil {
+			pass, err := sc.Filter.Filter(path)
+			if err != nil {
+				return err
+			}
+			if !pass {
+				continue
+			}
+		}
+		if err := sc.scan(ctx, dst, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
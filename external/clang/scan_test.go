@@ -0,0 +1,321 @@
+package clang
+
+import (
+	"testing"
+	"github.com/phyrwork/mobius/store"
+	"github.com/cayleygraph/cayley"
+	"github.com/phyrwork/mobius/fs"
+	"context"
+	"github.com/spf13/afero"
+	"github.com/phyrwork/mobius/clang"
+	ext "github.com/phyrwork/mobius/external/fs"
+	"github.com/cayleygraph/cayley/quad"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func newStore(t *testing.T) *store.Store {
+	g, err := cayley.NewMemoryGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store.New(g)
+}
+
+func newFs(ctx context.Context, t *testing.T) *fs.Fs {
+	s := newStore(t)
+	f, err := fs.NewFs(ctx, s)
+	if err != nil {
+		t.Skipf("error creating fs: %v", err)
+	}
+	return f
+}
+
+func TestScanner_Scan(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		imported []string
+		includes map[string][]string
+	}{
+		{
+			"includes",
+			map[string]string{
+				"a/b.c": "#include \"b.h\"\n\nint main() {}\n#include <c/d.h>\n",
+				"a/b.h": "",
+			},
+			[]string{"a/b.c", "a/b.h"},
+			map[string][]string{
+				"a/b.c": {"#include \"b.h\"", "#include <c/d.h>"},
+				"a/b.h": nil,
+			},
+		},
+		{
+			"not imported",
+			map[string]string{
+				"a/b.c": "#include \"b.h\"\n",
+				"a/b.h": "#include <c.h>\n",
+			},
+			[]string{"a/b.c"},
+			map[string][]string{
+				"a/b.c": {"#include \"b.h\""},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			io := afero.NewMemMapFs()
+			for path, text := range test.files {
+				afero.WriteFile(io, "/"+path, []byte(text), 0644)
+			}
+			s := newFs(ctx, t)
+			for _, path := range test.imported {
+				if _, err := s.Create(ctx, path); err != nil {
+					t.Skipf("error creating test file %v: %v", path, err)
+				}
+			}
+			// Test
+			sc := NewScanner(io, "/")
+			if err := sc.Scan(ctx, s); err != nil {
+				t.Fatalf("error scanning: %v", err)
+			}
+			for path, e := range test.includes {
+				var f clang.File
+				node, err := s.Lookup(ctx, &f, path)
+				if err != nil || node == nil {
+					t.Skipf("file lookup error: %v", err)
+				}
+				var a []string
+				for _, d := range f.Includes {
+					a = append(a, d.Text)
+				}
+				if len(a) != len(e) {
+					t.Fatalf("unexpected includes for %v: expected %v, got %v", path, e, a)
+				}
+				// Load order is not defined
+				for _, i := range e {
+					found := false
+					for _, j := range a {
+						found = found || i == j
+					}
+					if !found {
+						t.Fatalf("unexpected includes for %v: expected %v, got %v", path, e, a)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestScanner_ScanPaths_Sync(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	io := afero.NewMemMapFs()
+	for path, text := range map[string]string{
+		"src/a.c": "#include \"b.h\"\n#include <c.h>\n",
+		"inc/b.h": "",
+		"inc/c.h": "",
+	} {
+		afero.WriteFile(io, "/"+path, []byte(text), 0644)
+	}
+	s := newFs(ctx, t)
+	if err := ext.NewImporter(io, "/").Import(ctx, s); err != nil {
+		t.Skipf("error importing: %v", err)
+	}
+	sc := NewScanner(io, "/")
+	if err := sc.Scan(ctx, s); err != nil {
+		t.Skipf("error scanning: %v", err)
+	}
+	p, errs := clang.NewOrderedPath(s, "inc")
+	if len(errs) > 0 {
+		t.Skipf("error initializing include path: %v", errs)
+	}
+	if errs := clang.LinkAll(ctx, s, p); len(errs) > 0 {
+		t.Skipf("error linking: %v", errs)
+	}
+	// Shadow inc/b.h with a header beside the source
+	afero.WriteFile(io, "/src/b.h", []byte(""), 0644)
+	// Test
+	created, modified, _, err := ext.NewImporter(io, "/").Sync(ctx, s)
+	if err != nil {
+		t.Fatalf("error syncing: %v", err)
+	}
+	changes := clang.NewChanges()
+	for _, path := range created {
+		node, err := s.LookupLink(ctx, nil, path)
+		if err != nil || node == nil {
+			t.Fatalf("file lookup error: %v", err)
+		}
+		changes.AddCreated(node, path)
+	}
+	if err := sc.ScanPaths(ctx, s, append(created, modified...)...); err != nil {
+		t.Fatalf("error scanning: %v", err)
+	}
+	if errs := clang.RelinkAll(ctx, s, p, &changes); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	src, err := s.Lookup(ctx, nil, "src/a.c")
+	if err != nil || src == nil {
+		t.Fatalf("file lookup error: %v", err)
+	}
+	depends, err := clang.ReadDepends(ctx, s.Store, src)
+	if err != nil {
+		t.Fatalf("error reading depends: %v", err)
+	}
+	e := make(map[quad.Value]struct{})
+	for _, path := range []string{"src/b.h", "inc/c.h"} {
+		node, err := s.Lookup(ctx, nil, path)
+		if err != nil || node == nil {
+			t.Fatalf("file lookup error: %v", err)
+		}
+		e[node] = struct{}{}
+	}
+	if len(depends) != len(e) {
+		t.Fatalf("unexpected depends: %v", depends)
+	}
+	for _, dep := range depends {
+		if _, ok := e[dep.To]; !ok {
+			t.Fatalf("unexpected depend: %v", dep.To)
+		}
+	}
+}
+
+func TestScanner_ScanPaths_SyncSymlink(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	root, err := ioutil.TempDir("", "mobius")
+	if err != nil {
+		t.Skipf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(root)
+	io := afero.NewOsFs()
+	for path, text := range map[string]string{
+		"src/a.c": "#include <a.h>\n",
+		"v1/a.h":  "",
+		"v2/a.h":  "",
+	} {
+		io.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755)
+		if err := afero.WriteFile(io, filepath.Join(root, path), []byte(text), 0644); err != nil {
+			t.Skipf("error writing file: %v", err)
+		}
+	}
+	if err := os.Symlink("v1", filepath.Join(root, "inc")); err != nil {
+		t.Skipf("error creating symlink: %v", err)
+	}
+	s := newFs(ctx, t)
+	if err := ext.NewImporter(io, root).Import(ctx, s); err != nil {
+		t.Skipf("error importing: %v", err)
+	}
+	if errs := s.ResolveLinks(ctx); len(errs) > 0 {
+		t.Skipf("error resolving links: %v", errs)
+	}
+	sc := NewScanner(io, root)
+	if err := sc.Scan(ctx, s); err != nil {
+		t.Skipf("error scanning: %v", err)
+	}
+	p, errs := clang.NewOrderedPath(s, "inc")
+	if len(errs) > 0 {
+		t.Skipf("error initializing include path: %v", errs)
+	}
+	if errs := clang.LinkAll(ctx, s, p); len(errs) > 0 {
+		t.Skipf("error linking: %v", errs)
+	}
+	// Retarget the include directory
+	if err := os.Remove(filepath.Join(root, "inc")); err != nil {
+		t.Skipf("error removing symlink: %v", err)
+	}
+	if err := os.Symlink("v2", filepath.Join(root, "inc")); err != nil {
+		t.Skipf("error creating symlink: %v", err)
+	}
+	// Test
+	before, err := s.LinkTargets(ctx)
+	if err != nil {
+		t.Fatalf("error reading symlinks: %v", err)
+	}
+	created, modified, _, err := ext.NewImporter(io, root).Sync(ctx, s)
+	if err != nil {
+		t.Fatalf("error syncing: %v", err)
+	}
+	if len(modified) != 1 || modified[0] != "inc" {
+		t.Fatalf("unexpected modified: expected [inc], got %v", modified)
+	}
+	if errs := s.ResolveLinks(ctx); len(errs) > 0 {
+		t.Fatalf("error resolving links: %v", errs)
+	}
+	after, err := s.LinkTargets(ctx)
+	if err != nil {
+		t.Fatalf("error reading symlinks: %v", err)
+	}
+	changes := clang.NewChanges()
+	changes.AddLinks(before, after)
+	if err := sc.ScanPaths(ctx, s, append(created, modified...)...); err != nil {
+		t.Fatalf("error scanning: %v", err)
+	}
+	if p, errs = clang.NewOrderedPath(s, "inc"); len(errs) > 0 {
+		t.Fatalf("error initializing include path: %v", errs)
+	}
+	if errs := clang.RelinkAll(ctx, s, p, &changes); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	src, err := s.Lookup(ctx, nil, "src/a.c")
+	if err != nil || src == nil {
+		t.Fatalf("file lookup error: %v", err)
+	}
+	depends, err := clang.ReadDepends(ctx, s.Store, src)
+	if err != nil {
+		t.Fatalf("error reading depends: %v", err)
+	}
+	e, err := s.Lookup(ctx, nil, "v2/a.h")
+	if err != nil || e == nil {
+		t.Fatalf("file lookup error: %v", err)
+	}
+	if len(depends) != 1 || depends[0].To != e {
+		t.Fatalf("unexpected depends: expected %v, got %v", e, depends)
+	}
+}
+
+func TestNewScanner_RelativeRoot(t *testing.T) {
+	for _, root := range []string{".", "src"} {
+		t.Run(root, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			dir, err := ioutil.TempDir("", "mobius")
+			if err != nil {
+				t.Skipf("error creating temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Skipf("error getting working dir: %v", err)
+			}
+			if err := os.Chdir(dir); err != nil {
+				t.Skipf("error changing working dir: %v", err)
+			}
+			defer os.Chdir(wd)
+			io := afero.NewOsFs()
+			io.MkdirAll(filepath.Join(root, "a"), 0755)
+			if err := afero.WriteFile(io, filepath.Join(root, "a/b.c"), []byte("#include \"b.h\"\n"), 0644); err != nil {
+				t.Skipf("error writing file: %v", err)
+			}
+			s := newFs(ctx, t)
+			if _, err := s.Create(ctx, "a/b.c"); err != nil {
+				t.Skipf("error creating test file: %v", err)
+			}
+			// Test
+			if err := NewScanner(io, root).Scan(ctx, s); err != nil {
+				t.Fatalf("error scanning: %v", err)
+			}
+			var f clang.File
+			node, err := s.Lookup(ctx, &f, "a/b.c")
+			if err != nil || node == nil {
+				t.Fatalf("file lookup error: %v", err)
+			}
+			if len(f.Includes) != 1 || f.Includes[0].Text != "#include \"b.h\"" {
+				t.Fatalf("unexpected includes: %v", f.Includes)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package compdb
+
+import (
+	"context"
+	"github.com/spf13/afero"
+	"github.com/phyrwork/mobius/fs"
+	"github.com/phyrwork/mobius/clang"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"github.com/cayleygraph/cayley/quad"
+)
+
+// Command is an entry of a JSON compilation database (compile_commands.json).
+type Command struct {
+	Directory string   `json:"directory"`
+	File      string   `json:"file"`
+	Command   string   `json:"command,omitempty"`
+	Arguments []string `json:"arguments,omitempty"`
+	Output    string   `json:"output,omitempty"`
+}
+
+func (c Command) Args() []string {
+	if len(c.Arguments) > 0 {
+		return c.Arguments
+	}
+	return SplitCommand(c.Command)
+}
+
+// SplitCommand splits a command line into arguments as a POSIX shell would,
+// without expansion.
+func SplitCommand(s string) (args []string) {
+	var arg strings.Builder
+	in := false
+	var quote rune
+	escape := false
+	for _, r := range s {
+		switch {
+		case escape:
+			arg.WriteRune(r)
+			escape = false
+		case r == '\\' && quote != '\'':
+			escape = true
+			in = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			in = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if in {
+				args = append(args, arg.String())
+				arg.Reset()
+				in = false
+			}
+		default:
+			arg.WriteRune(r)
+			in = true
+		}
+	}
+	if in {
+		args = append(args, arg.String())
+	}
+	return
+}
+
+type Importer struct {
+	io   afero.Fs
+	root string
+	db   string
+}
+
+// NewImporter creates an Importer for the compilation database at db. Paths in
+// the database are mapped into the graph relative to root.
+func NewImporter(io afero.Fs, root string, db string) Importer {
+	return Importer{
+		io:   io,
+		root: filepath.Clean(root),
+		db:   db,
+	}
+}
+
+func (im Importer) Read() (cmds []Command, err error) {
+	b, err := afero.ReadFile(im.io, im.db)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(b, &cmds)
+	return
+}
+
+func (im Importer) path(dir string, p string) (string, error) {
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	rel, err := filepath.Rel(im.root, p)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%v outside root %v", p, im.root)
+	}
+	return rel, nil
+}
+
+func (im Importer) lookup(ctx context.Context, dst *fs.Fs, dir string, p string) (quad.IRI, error) {
+	rel, err := im.path(dir, p)
+	if err != nil {
+		return "", err
+	}
+	f, err := dst.Open(ctx, fs.Literal(rel))
+	if err != nil {
+		return "", err
+	}
+	return f.IRI, nil
+}
+
+func (im Importer) unit(ctx context.Context, dst *fs.Fs, cmd Command) (u clang.Unit, errs []error) {
+	src, err := im.lookup(ctx, dst, cmd.Directory, cmd.File)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error finding source: %v", err))
+		return
+	}
+	u.Source = src
+	args := cmd.Args()
+	if len(args) > 0 {
+		// Skip compiler
+		args = args[1:]
+	}
+	opts := clang.ParseArgs(args)
+	for _, l := range []struct {
+		list int
+		dirs []string
+	}{
+		{clang.ListQuote, opts.Quote},
+		{clang.ListAngle, opts.Angle},
+		{clang.ListSystem, opts.System},
+		{clang.ListAfter, opts.After},
+	} {
+		for _, d := range l.dirs {
+			dir, err := im.lookup(ctx, dst, cmd.Directory, d)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error finding include directory: %v", err))
+				continue
+			}
+			u.AddDir(l.list, dir)
+		}
+	}
+	u.Defines = opts.Defines
+	return
+}
+
+func (im Importer) Import(ctx context.Context, dst *fs.Fs) (errs []error) {
+	cmds, err := im.Read()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error reading compilation database %v: %v", im.db, err))
+		return
+	}
+	for _, cmd := range cmds {
+		u, uerrs := im.unit(ctx, dst, cmd)
+		for _, err := range uerrs {
+			errs = append(errs, fmt.Errorf("%v: %v", cmd.File, err))
+		}
+		if len(u.Source) == 0 {
+			continue
+		}
+		if _, err := clang.WriteUnit(dst, u); err != nil {
+			errs = append(errs, fmt.Errorf("%v: error writing unit: %v", cmd.File, err))
+		}
+	}
+	return
+}
@@ -0,0 +1,142 @@
+package compdb
+
+import (
+	"testing"
+	"github.com/phyrwork/mobius/store"
+	"github.com/cayleygraph/cayley"
+	"github.com/phyrwork/mobius/fs"
+	"context"
+	"github.com/spf13/afero"
+	"github.com/phyrwork/mobius/clang"
+	"reflect"
+	"github.com/cayleygraph/cayley/quad"
+)
+
+func newStore(t *testing.T) *store.Store {
+	g, err := cayley.NewMemoryGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store.New(g)
+}
+
+func newFs(ctx context.Context, t *testing.T) *fs.Fs {
+	s := newStore(t)
+	f, err := fs.NewFs(ctx, s)
+	if err != nil {
+		t.Skipf("error creating fs: %v", err)
+	}
+	return f
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  string
+		args []string
+	}{
+		{
+			"simple",
+			"cc -c a.c",
+			[]string{"cc", "-c", "a.c"},
+		},
+		{
+			"whitespace",
+			"  cc\t-c   a.c ",
+			[]string{"cc", "-c", "a.c"},
+		},
+		{
+			"double quoted",
+			"cc -I\"a b\" -DA=\"\\\"b\\\"\"",
+			[]string{"cc", "-Ia b", "-DA=\"b\""},
+		},
+		{
+			"single quoted",
+			"cc '-Ia b' -DA='\\'",
+			[]string{"cc", "-Ia b", "-DA=\\"},
+		},
+		{
+			"escaped space",
+			"cc -Ia\\ b",
+			[]string{"cc", "-Ia b"},
+		},
+		{
+			"empty argument",
+			"cc \"\"",
+			[]string{"cc", ""},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := SplitCommand(test.cmd)
+			if !reflect.DeepEqual(test.args, a) {
+				t.Fatalf("unexpected args: expected %q, got %q", test.args, a)
+			}
+		})
+	}
+}
+
+func TestImporter_Import(t *testing.T) {
+	ctx := context.TODO()
+	// Setup
+	io := afero.NewMemMapFs()
+	db := `[
+		{"directory": "/src", "file": "a.c", "command": "cc -Iinc -isystem /src/sys -DX=1 -c a.c"},
+		{"directory": "/src/b", "file": "/src/b/b.c", "arguments": ["cc", "-iquote", "../inc", "-c", "b.c"]},
+		{"directory": "/src", "file": "/other/c.c", "command": "cc -c /other/c.c"}
+	]`
+	if err := afero.WriteFile(io, "/build/compile_commands.json", []byte(db), 0644); err != nil {
+		t.Skipf("error writing database: %v", err)
+	}
+	s := newFs(ctx, t)
+	files := make(map[string]quad.IRI)
+	for _, p := range []string{"a.c", "b/b.c", "inc", "sys"} {
+		f, err := s.Create(ctx, p)
+		if err != nil {
+			t.Skipf("error creating test file %v: %v", p, err)
+		}
+		files[p] = f.IRI
+	}
+	// Test
+	im := NewImporter(io, "/src", "/build/compile_commands.json")
+	errs := im.Import(ctx, s)
+	if len(errs) != 1 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	var units []clang.Unit
+	if err := s.Store.Select(ctx, &units); err != nil {
+		t.Fatalf("error reading units: %v", err)
+	}
+	e := map[quad.IRI]struct {
+		dirs    map[quad.IRI]int
+		defines []string
+	}{
+		files["a.c"]: {
+			map[quad.IRI]int{files["inc"]: clang.ListAngle, files["sys"]: clang.ListSystem},
+			[]string{"X=1"},
+		},
+		files["b/b.c"]: {
+			map[quad.IRI]int{files["inc"]: clang.ListQuote},
+			nil,
+		},
+	}
+	if len(units) != len(e) {
+		t.Fatalf("unexpected units: %v", units)
+	}
+	for _, u := range units {
+		eu, ok := e[u.Source]
+		if !ok {
+			t.Fatalf("unexpected unit source: %v", u.Source)
+		}
+		dirs := make(map[quad.IRI]int)
+		for _, d := range u.Dirs {
+			dirs[d.Dir] = d.List
+		}
+		if !reflect.DeepEqual(eu.dirs, dirs) {
+			t.Fatalf("unexpected search dirs for %v: expected %v, got %v", u.Source, eu.dirs, dirs)
+		}
+		if (len(eu.defines) > 0 || len(u.Defines) > 0) && !reflect.DeepEqual(eu.defines, u.Defines) {
+			t.Fatalf("unexpected defines for %v: expected %v, got %v", u.Source, eu.defines, u.Defines)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package fs
+
+import (
+	"context"
+	"github.com/spf13/afero"
+	"github.com/phyrwork/mobius/fs"
+	"os"
+	"io"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"bytes"
+	"io/ioutil"
+	"github.com/cayleygraph/cayley/quad"
+)
+
+// Source provides the content of files in a GraphFs.
+type Source interface {
+	Open(path string, f fs.File) (io.ReadCloser, error)
+}
+
+// FsSource reads file content from a source tree.
+type FsSource struct {
+	io afero.Fs
+}
+
+func NewFsSource(io afero.Fs, root string) FsSource {
+	return FsSource{io: RootFs(io, root)}
+}
+
+// RootFs returns io with paths relative to root. A relative base path only
+// admits paths with its prefix, so for the current directory io is returned
+// as it is, to be walked from ".".
+func RootFs(io afero.Fs, root string) afero.Fs {
+	if root = filepath.Clean(root); root == "." {
+		return io
+	}
+	return afero.NewBasePathFs(io, root)
+}
+
+func (s FsSource) Open(path string, _ fs.File) (io.ReadCloser, error) {
+	return s.io.Open(path)
+}
+
+// GraphFs is a read-only afero.Fs answering from the nodes of a graph
+// filesystem. File content is read from Source; without one files are empty.
+type GraphFs struct {
+	Fs     *fs.Fs
+	Source Source
+	ctx    context.Context
+}
+
+func NewGraphFs(ctx context.Context, f *fs.Fs, src Source) *GraphFs {
+	return &GraphFs{Fs: f, Source: src, ctx: ctx}
+}
+
+// clean makes name relative to the root of the graph filesystem.
+func clean(name string) string {
+	return filepath.Clean(strings.TrimPrefix(filepath.ToSlash(name), "/"))
+}
+
+func (g *GraphFs) Name() string {
+	return "GraphFs"
+}
+
+func (g *GraphFs) open(op, name string) (fs.File, error) {
+	return g.lookup(op, name, g.Fs.Lookup)
+}
+
+func (g *GraphFs) lookup(op, name string, lookup func(context.Context, interface{}, string) (quad.Value, error)) (f fs.File, err error) {
+	node, err := lookup(g.ctx, &f, clean(name))
+	if err == nil && node == nil {
+		err = os.ErrNotExist
+	}
+	if err != nil {
+		err = &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return
+}
+
+func (g *GraphFs) Open(name string) (afero.File, error) {
+	f, err := g.open("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &file{g: g, name: name, f: f}, nil
+}
+
+func (g *GraphFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EPERM}
+	}
+	return g.Open(name)
+}
+
+func (g *GraphFs) Stat(name string) (os.FileInfo, error) {
+	f, err := g.open("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{f}, nil
+}
+
+func (g *GraphFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	f, err := g.lookup("lstat", name, g.Fs.LookupLink)
+	if err != nil {
+		return nil, true, err
+	}
+	return fileInfo{f}, true, nil
+}
+
+func (g *GraphFs) ReadlinkIfPossible(name string) (string, error) {
+	f, err := g.lookup("readlink", name, g.Fs.LookupLink)
+	if err != nil {
+		return "", err
+	}
+	if f.Kind != fs.KindSymlink {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: syscall.EINVAL}
+	}
+	return f.Link, nil
+}
+
+func (g *GraphFs) Create(name string) (afero.File, error) {
+	return nil, &os.PathError{Op: "create", Path: name, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) Mkdir(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) MkdirAll(path string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: path, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) RemoveAll(path string) error {
+	return &os.PathError{Op: "remove", Path: path, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) Chmod(name string, mode os.FileMode) error {
+	return &os.PathError{Op: "chmod", Path: name, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) Chown(name string, uid, gid int) error {
+	return &os.PathError{Op: "chown", Path: name, Err: syscall.EPERM}
+}
+
+func (g *GraphFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return &os.PathError{Op: "chtimes", Path: name, Err: syscall.EPERM}
+}
+
+type fileInfo struct {
+	f fs.File
+}
+
+func (i fileInfo) Name() string {
+	return i.f.Name
+}
+
+func (i fileInfo) Size() int64 {
+	return i.f.Size
+}
+
+func (i fileInfo) Mode() os.FileMode {
+	switch i.f.Kind {
+	case fs.KindDir:
+		return os.ModeDir | 0555
+	case fs.KindSymlink:
+		return os.ModeSymlink | 0777
+	}
+	return 0444
+}
+
+func (i fileInfo) ModTime() time.Time {
+	return i.f.ModTime
+}
+
+func (i fileInfo) IsDir() bool {
+	return i.f.Kind == fs.KindDir
+}
+
+func (i fileInfo) Sys() interface{} {
+	return i.f
+}
+
+// file is an open GraphFs file. Content is read from the source on first use.
+type file struct {
+	g       *GraphFs
+	name    string
+	f       fs.File
+	content *bytes.Reader
+	dir     []fs.File
+	read    bool
+	closed  bool
+}
+
+func (f *file) load() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.content != nil {
+		return nil
+	}
+	if (fileInfo{f.f}).IsDir() {
+		return &os.PathError{Op: "read", Path: f.name, Err: syscall.EISDIR}
+	}
+	var b []byte
+	if f.g.Source != nil {
+		r, err := f.g.Source.Open(clean(f.name), f.f)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if b, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	f.content = bytes.NewReader(b)
+	return nil
+}
+
+func (f *file) Close() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.content.Read(p)
+}
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.content.ReadAt(p, off)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if err := f.load(); err != nil {
+		return 0, err
+	}
+	return f.content.Seek(offset, whence)
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *file) WriteAt(p []byte, off int64) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *file) WriteString(s string) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *file) Truncate(size int64) error {
+	return &os.PathError{Op: "truncate", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *file) Sync() error {
+	return nil
+}
+
+func (f *file) Name() string {
+	return f.name
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	return fileInfo{f.f}, nil
+}
+
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	if f.closed {
+		return nil, os.ErrClosed
+	}
+	if f.f.Kind == fs.KindFile {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+	if !f.read {
+		dir, err := f.g.Fs.ReadDir(f.g.ctx, clean(f.name))
+		if err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: f.name, Err: err}
+		}
+		f.dir, f.read = dir, true
+	}
+	n := len(f.dir)
+	if count > 0 {
+		if n == 0 {
+			return nil, io.EOF
+		}
+		if count < n {
+			n = count
+		}
+	}
+	infos := make([]os.FileInfo, n)
+	for i := range infos {
+		infos[i] = fileInfo{f.dir[i]}
+	}
+	f.dir = f.dir[n:]
+	return infos, nil
+}
+
+func (f *file) Readdirnames(n int) ([]string, error) {
+	infos, err := f.Readdir(n)
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, err
+}
@@ -0,0 +1,114 @@
+package fs
+
+import (
+	"testing"
+	"context"
+	"github.com/spf13/afero"
+	"os"
+	"reflect"
+	"sort"
+)
+
+func TestGraphFs(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			"flat",
+			map[string]string{"a.c": "a", "b.h": ""},
+		},
+		{
+			"nested",
+			map[string]string{"a/b.c": "#include \"b.h\"\n", "a/b.h": "b", "c/d/e.h": "e"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			io := afero.NewMemMapFs()
+			for path, text := range test.files {
+				afero.WriteFile(io, "/"+path, []byte(text), 0644)
+			}
+			s := newFs(ctx, t)
+			if err := NewImporter(io, "/").Import(ctx, s); err != nil {
+				t.Skipf("error importing: %v", err)
+			}
+			// Test
+			g := NewGraphFs(ctx, s, NewFsSource(io, "/"))
+			for path, text := range test.files {
+				info, err := g.Stat("/" + path)
+				if err != nil {
+					t.Fatalf("error getting file info: %v", err)
+				}
+				if info.IsDir() || info.Size() != int64(len(text)) {
+					t.Fatalf("unexpected file info for %v: %+v", path, info)
+				}
+				b, err := afero.ReadFile(g, path)
+				if err != nil {
+					t.Fatalf("error reading file: %v", err)
+				}
+				if string(b) != text {
+					t.Fatalf("unexpected content for %v: expected %q, got %q", path, text, b)
+				}
+			}
+			// Importer runs against the graph filesystem
+			var e, a []string
+			afero.Walk(io, "/", func(path string, _ os.FileInfo, _ error) error {
+				e = append(e, path)
+				return nil
+			})
+			err := afero.Walk(g, "/", func(path string, _ os.FileInfo, err error) error {
+				a = append(a, path)
+				return err
+			})
+			if err != nil {
+				t.Fatalf("error walking: %v", err)
+			}
+			sort.Strings(e)
+			sort.Strings(a)
+			if !reflect.DeepEqual(e, a) {
+				t.Fatalf("unexpected paths: expected %v, got %v", e, a)
+			}
+			d := newFs(ctx, t)
+			if err := NewImporter(g, "/").Import(ctx, d); err != nil {
+				t.Fatalf("error importing graph filesystem: %v", err)
+			}
+			for path := range test.files {
+				ef, err := s.Open(ctx, path)
+				if err != nil {
+					t.Skipf("error opening file: %v", err)
+				}
+				af, err := d.Open(ctx, path)
+				if err != nil {
+					t.Fatalf("error opening file: %v", err)
+				}
+				if af.Hash != ef.Hash {
+					t.Fatalf("unexpected hash for %v: expected %v, got %v", path, ef.Hash, af.Hash)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphFs_ReadOnly(t *testing.T) {
+	ctx := context.TODO()
+	s := newFs(ctx, t)
+	if _, err := s.Create(ctx, "a"); err != nil {
+		t.Skipf("error creating file: %v", err)
+	}
+	g := NewGraphFs(ctx, s, nil)
+	if _, err := g.Stat("b"); !os.IsNotExist(err) {
+		t.Fatalf("unexpected error: expected not exist, got %v", err)
+	}
+	if _, err := g.OpenFile("a", os.O_RDWR, 0); !os.IsPermission(err) {
+		t.Fatalf("unexpected error: expected permission, got %v", err)
+	}
+	if err := g.Remove("a"); !os.IsPermission(err) {
+		t.Fatalf("unexpected error: expected permission, got %v", err)
+	}
+	if _, err := s.Open(ctx, "a"); err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+}
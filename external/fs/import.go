@@ -7,25 +7,42 @@ import (
 	"os"
 	"github.com/phyrwork/mobius/fs"
 	"fmt"
+	"path/filepath"
+	"github.com/cayleygraph/cayley/quad"
+	"sort"
+	"crypto/sha256"
+	"io"
+	"encoding/hex"
+	"strings"
 )
 
+// Importer imports a source tree into a graph filesystem. Symlinks are
+// imported as symlinks, not followed; call fs.Fs.ResolveLinks after importing.
 type Importer struct {
 	io afero.Fs
+	root string
 	Filter filter.Filter
+	// Roots are the directories of the named roots, by name, so that
+	// absolute symlink targets in them are imported relative to the root.
+	Roots map[string]string
 }
 
 func NewImporter(io afero.Fs, root string) Importer {
-	io = afero.NewBasePathFs(io, root)
-	return Importer{io: io}
+	im := Importer{io: RootFs(io, root), root: root}
+	if abs, err := filepath.Abs(root); err == nil {
+		im.root = abs
+	}
+	return im
 }
 
-func (im Importer) Import(ctx context.Context, dst *fs.Fs) error {
-	return afero.Walk(im.io, "", func (path string, info os.FileInfo, err error) error {
+// walk calls fn for each path of the source tree that passes the filter.
+func (im Importer) walk(fn func(path string, info os.FileInfo) error) error {
+	return afero.Walk(im.io, ".", func (path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 		// Ignore root directory
-		if path == "" {
+		if path == "." {
 			return nil
 		}
 		// Filter
@@ -38,9 +55,202 @@ func (im Importer) Import(ctx context.Context, dst *fs.Fs) error {
 				return nil
 			}
 		}
-		if _, err := dst.Create(ctx, path); err != nil {
+		return fn(path, info)
+	})
+}
+
+// hash returns the hex SHA-256 digest of the content of the file at path.
+func (im Importer) hash(path string) (string, error) {
+	r, err := im.io.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// below returns target relative to dir, if it is inside dir.
+func below(dir, target string) (string, bool) {
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// readlink returns the target of the symlink at path. Absolute targets inside
+// the source tree are made relative, and those inside another root are made
+// relative to that root, e.g. @sdk/include.
+func (im Importer) readlink(path string) (string, error) {
+	lr, ok := im.io.(afero.LinkReader)
+	if !ok {
+		return "", fmt.Errorf("error reading symlink: not supported by %v", im.io.Name())
+	}
+	target, err := lr.ReadlinkIfPossible(path)
+	if err != nil || !filepath.IsAbs(target) {
+		return target, err
+	}
+	if rel, ok := below(im.root, target); ok {
+		return filepath.Rel(filepath.Dir(path), rel)
+	}
+	// The deepest root, if roots are nested
+	link, depth := target, -1
+	for name, dir := range im.Roots {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if rel, ok := below(dir, target); ok && len(dir) > depth {
+			link, depth = fs.RootPrefix+name+"/"+filepath.ToSlash(rel), len(dir)
+		}
+	}
+	return link, nil
+}
+
+// meta returns the metadata of the file at path. The content is only hashed
+// if hash is set.
+func (im Importer) meta(path string, info os.FileInfo, hash bool) (m fs.Meta, err error) {
+	m.ModTime = info.ModTime()
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		m.Kind = fs.KindSymlink
+		m.Link, err = im.readlink(path)
+	case info.IsDir():
+		m.Kind = fs.KindDir
+	case info.Mode().IsRegular():
+		m.Kind = fs.KindFile
+		m.Size = info.Size()
+		if hash {
+			m.Hash, err = im.hash(path)
+		}
+	}
+	return
+}
+
+// create creates the graph file for path with metadata m.
+func create(ctx context.Context, dst *fs.Fs, path string, m fs.Meta) (fs.File, error) {
+	// Source paths never name a root
+	path = fs.Literal(path)
+	if m.Kind == fs.KindSymlink {
+		return dst.Symlink(ctx, path, m.Link)
+	}
+	return dst.Create(ctx, path)
+}
+
+func (im Importer) Import(ctx context.Context, dst *fs.Fs) error {
+	return im.walk(func (path string, info os.FileInfo) error {
+		m, err := im.meta(path, info, true)
+		if err != nil {
+			return fmt.Errorf("error reading %v: %v", path, err)
+		}
+		f, err := create(ctx, dst, path, m)
+		if err != nil {
 			return fmt.Errorf("error creating graph file %v: %v", path, err)
 		}
+		if err := dst.SetMeta(ctx, f.IRI, m); err != nil {
+			return fmt.Errorf("error writing metadata for %v: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// files returns the node of every path in the graph below the root.
+func files(ctx context.Context, dst *fs.Fs) (map[string]quad.Value, error) {
+	nodes := make(map[string]quad.Value)
+	err := dst.Walk(ctx, ".", func(p string, f fs.File, err error) error {
+		if err != nil {
+			return fmt.Errorf("error listing graph directory %v: %v", p, err)
+		}
+		if p != "." {
+			nodes[p] = f.IRI
+		}
+		return nil
+	})
+	return nodes, err
+}
+
+// Sync updates the graph to match the source tree. Paths new to the tree are
+// created and paths no longer in it are removed, along with any edges to or
+// from their nodes. Nodes of paths in both are left as they are, but files
+// whose content changed since they were imported, symlinks whose target
+// changed and paths that changed kind are listed as modified. Content is only
+// hashed if the size or modification time changed.
+func (im Importer) Sync(ctx context.Context, dst *fs.Fs) (created, modified, removed []string, err error) {
+	stale, err := files(ctx, dst)
+	if err != nil {
+		return
+	}
+	err = im.walk(func (p string, info os.FileInfo) error {
+		m, err := im.meta(p, info, false)
+		if err != nil {
+			return fmt.Errorf("error reading %v: %v", p, err)
+		}
+		var f fs.File
+		if node, ok := stale[p]; ok {
+			if err := dst.Store.Select(ctx, &f, node); err != nil {
+				return fmt.Errorf("error reading graph file %v: %v", p, err)
+			}
+			if m.Kind == f.Kind && m.Size == f.Size && m.ModTime.Equal(f.ModTime) && m.Link == f.Link && (m.Kind != fs.KindFile || f.Hash != "") {
+				// Unchanged
+				m = f.Meta
+			} else if m.Kind == fs.KindFile {
+				if m.Hash, err = im.hash(p); err != nil {
+					return fmt.Errorf("error reading %v: %v", p, err)
+				}
+				if m.Hash != f.Hash {
+					modified = append(modified, p)
+				}
+			} else if m.Kind != f.Kind || m.Link != f.Link {
+				modified = append(modified, p)
+			}
+		} else {
+			if f, err = create(ctx, dst, p, m); err != nil {
+				return fmt.Errorf("error creating graph file %v: %v", p, err)
+			}
+			if m.Kind == fs.KindFile {
+				if m.Hash, err = im.hash(p); err != nil {
+					return fmt.Errorf("error reading %v: %v", p, err)
+				}
+			}
+			created = append(created, p)
+		}
+		if m != f.Meta {
+			if err := dst.SetMeta(ctx, f.IRI, m); err != nil {
+				return fmt.Errorf("error writing metadata for %v: %v", p, err)
+			}
+		}
+		// Directories created for a path are kept with it
+		for up := fs.Path(p); up != "."; up = up.Up() {
+			delete(stale, up.String())
+		}
 		return nil
 	})
-}
\ No newline at end of file
+	if err != nil {
+		return
+	}
+	for p := range stale {
+		removed = append(removed, p)
+	}
+	sort.Strings(removed)
+	// Everything under a stale directory is stale, so removing the directory
+	// removes its children too
+	for _, p := range removed {
+		up := fs.Path(p).Up()
+		for ; up != "."; up = up.Up() {
+			if _, ok := stale[up.String()]; ok {
+				break
+			}
+		}
+		if up != "." {
+			continue
+		}
+		if err = dst.Remove(ctx, fs.Literal(p), true); err != nil {
+			err = fmt.Errorf("error removing graph file %v: %v", p, err)
+			return
+		}
+	}
+	return
+}
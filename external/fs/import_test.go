@@ -9,6 +9,14 @@ import (
 	"github.com/cayleygraph/cayley"
 	"github.com/phyrwork/mobius/fs"
 	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"sort"
+	"reflect"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 )
 
 func newStore(t *testing.T) *store.Store {
@@ -112,3 +120,286 @@ func TestImporter_Import(t *testing.T) {
 	}
 }
 
+func TestImporter_Sync(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   filter.Filter
+		before   map[string]string
+		after    map[string]string
+		created  []string
+		modified []string
+		removed  []string
+	}{
+		{
+			"unchanged",
+			nil,
+			map[string]string{"a/b.c": "b", "a/b.h": ""},
+			map[string]string{"a/b.c": "b", "a/b.h": ""},
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"created",
+			nil,
+			map[string]string{"a/b.c": ""},
+			map[string]string{"a/b.c": "", "a/b.h": "", "c/d.h": ""},
+			[]string{"a/b.h", "c", "c/d.h"},
+			nil,
+			nil,
+		},
+		{
+			"modified",
+			nil,
+			map[string]string{"a/b.c": "b", "a/b.h": "b"},
+			map[string]string{"a/b.c": "c", "a/b.h": "b"},
+			nil,
+			[]string{"a/b.c"},
+			nil,
+		},
+		{
+			"removed",
+			nil,
+			map[string]string{"a/b.c": "", "a/b.h": "", "c/d.h": ""},
+			map[string]string{"a/b.c": ""},
+			nil,
+			nil,
+			[]string{"a/b.h", "c", "c/d.h"},
+		},
+		{
+			"whitelist",
+			filter.OrFilter{List: []filter.Filter{
+				filter.RegexpFilter{Regexp: regexp.MustCompile(".*\\.c")},
+			}},
+			map[string]string{"a/b.c": "", "a/b.h": "", "c/d.c": ""},
+			map[string]string{"a/b.c": "", "a/b.h": ""},
+			nil,
+			nil,
+			[]string{"c", "c/d.c"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			io := afero.NewMemMapFs()
+			for path, text := range test.before {
+				afero.WriteFile(io, "/"+path, []byte(text), 0644)
+			}
+			s := newFs(ctx, t)
+			im := NewImporter(io, "/")
+			im.Filter = test.filter
+			if err := im.Import(ctx, s); err != nil {
+				t.Skipf("error importing: %v", err)
+			}
+			nodes := make(map[string]quad.Value)
+			for path := range test.before {
+				node, err := s.Lookup(ctx, nil, path)
+				if err != nil {
+					t.Skipf("file lookup error: %v", err)
+				}
+				nodes[path] = node
+			}
+			// Edge to be kept if both ends are kept
+			edge := quad.Make(nodes["a/b.c"], quad.IRI("test:edge"), nodes["a/b.c"], nil)
+			if err := s.Store.Graph.AddQuad(edge); err != nil {
+				t.Skipf("error adding edge: %v", err)
+			}
+			io = afero.NewMemMapFs()
+			for path, text := range test.after {
+				afero.WriteFile(io, "/"+path, []byte(text), 0644)
+			}
+			// Test
+			im = NewImporter(io, "/")
+			im.Filter = test.filter
+			created, modified, removed, err := im.Sync(ctx, s)
+			if err != nil {
+				t.Fatalf("error syncing: %v", err)
+			}
+			sort.Strings(created)
+			if (len(created) > 0 || len(test.created) > 0) && !reflect.DeepEqual(test.created, created) {
+				t.Fatalf("unexpected created: expected %v, got %v", test.created, created)
+			}
+			if (len(modified) > 0 || len(test.modified) > 0) && !reflect.DeepEqual(test.modified, modified) {
+				t.Fatalf("unexpected modified: expected %v, got %v", test.modified, modified)
+			}
+			if (len(removed) > 0 || len(test.removed) > 0) && !reflect.DeepEqual(test.removed, removed) {
+				t.Fatalf("unexpected removed: expected %v, got %v", test.removed, removed)
+			}
+			for _, path := range test.removed {
+				node, err := s.Lookup(ctx, nil, path)
+				if err != nil {
+					t.Fatalf("file lookup error: %v", err)
+				}
+				if node != nil {
+					t.Fatalf("removed file %v found", path)
+				}
+			}
+			for path, text := range test.after {
+				var f fs.File
+				node, err := s.Lookup(ctx, &f, path)
+				if err != nil {
+					t.Fatalf("file lookup error: %v", err)
+				}
+				if i := sort.SearchStrings(created, path); nodes[path] == nil && (i == len(created) || created[i] != path) {
+					// Filtered out
+					continue
+				}
+				if node == nil {
+					t.Fatalf("file %v not found", path)
+				}
+				if e, ok := nodes[path]; ok && node != e {
+					t.Fatalf("unchanged file %v replaced: expected %v, got %v", path, e, node)
+				}
+				h := sha256.Sum256([]byte(text))
+				if e := hex.EncodeToString(h[:]); f.Kind != fs.KindFile || f.Size != int64(len(text)) || f.Hash != e {
+					t.Fatalf("unexpected metadata for %v: %+v", path, f.Meta)
+				}
+			}
+			it := s.Store.Graph.QuadIterator(quad.Predicate, s.Store.Graph.ValueOf(quad.IRI("test:edge")))
+			if !it.Next(ctx) {
+				t.Fatalf("edge removed")
+			}
+		})
+	}
+}
+
+func TestImporter_Import_Symlink(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	root, err := ioutil.TempDir("", "mobius")
+	if err != nil {
+		t.Skipf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(root)
+	io := afero.NewOsFs()
+	io.MkdirAll(filepath.Join(root, "sdk/include"), 0755)
+	if err := afero.WriteFile(io, filepath.Join(root, "sdk/include/a.h"), []byte{}, 0644); err != nil {
+		t.Skipf("error writing file: %v", err)
+	}
+	os.Mkdir(filepath.Join(root, "vendor"), 0755)
+	for path, target := range map[string]string{
+		"vendor/sdk": "../sdk",
+		"inc":        filepath.Join(root, "sdk/include"),
+		"x":          "y",
+		"y":          "x",
+	} {
+		if err := os.Symlink(target, filepath.Join(root, path)); err != nil {
+			t.Skipf("error creating symlink: %v", err)
+		}
+	}
+	s := newFs(ctx, t)
+	// Test
+	if err := NewImporter(io, root).Import(ctx, s); err != nil {
+		t.Fatalf("error importing: %v", err)
+	}
+	if errs := s.ResolveLinks(ctx); len(errs) != 1 {
+		t.Fatalf("unexpected errors: expected one loop, got %v", errs)
+	}
+	e, err := s.Lookup(ctx, nil, "sdk/include/a.h")
+	if err != nil || e == nil {
+		t.Fatalf("file lookup error: %v", err)
+	}
+	for _, path := range []string{"vendor/sdk/include/a.h", "inc/a.h"} {
+		a, err := s.Lookup(ctx, nil, path)
+		if err != nil {
+			t.Fatalf("file lookup error: %v", err)
+		}
+		if a != e {
+			t.Fatalf("unexpected node for %v: expected %v, got %v", path, e, a)
+		}
+	}
+	if target, err := s.Readlink(ctx, "inc"); err != nil || target != "sdk/include" {
+		t.Fatalf("unexpected target: expected %v, got %v (%v)", "sdk/include", target, err)
+	}
+	// Symlinks survive a round trip through the graph filesystem
+	d := newFs(ctx, t)
+	if err := NewImporter(NewGraphFs(ctx, s, nil), "/").Import(ctx, d); err != nil {
+		t.Fatalf("error importing graph filesystem: %v", err)
+	}
+	if target, err := d.Readlink(ctx, "vendor/sdk"); err != nil || target != "../sdk" {
+		t.Fatalf("unexpected target: expected %v, got %v (%v)", "../sdk", target, err)
+	}
+}
+
+func TestImporter_Import_SymlinkRoots(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	root, err := ioutil.TempDir("", "mobius")
+	if err != nil {
+		t.Skipf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(root)
+	io := afero.NewOsFs()
+	io.MkdirAll(filepath.Join(root, "sdk/include"), 0755)
+	if err := afero.WriteFile(io, filepath.Join(root, "sdk/include/a.h"), []byte{}, 0644); err != nil {
+		t.Skipf("error writing file: %v", err)
+	}
+	io.MkdirAll(filepath.Join(root, "src"), 0755)
+	if err := os.Symlink(filepath.Join(root, "sdk/include"), filepath.Join(root, "src/inc")); err != nil {
+		t.Skipf("error creating symlink: %v", err)
+	}
+	s := newFs(ctx, t)
+	sdk, err := fs.NewNamedFs(ctx, s.Store, "sdk")
+	if err != nil {
+		t.Skipf("error creating fs: %v", err)
+	}
+	roots := map[string]string{"": filepath.Join(root, "src"), "sdk": filepath.Join(root, "sdk")}
+	for name, f := range map[string]*fs.Fs{"": s, "sdk": sdk} {
+		im := NewImporter(io, roots[name])
+		im.Roots = roots
+		if err := im.Import(ctx, f); err != nil {
+			t.Fatalf("error importing: %v", err)
+		}
+	}
+	// Test
+	if errs := s.ResolveLinks(ctx); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if target, err := s.Readlink(ctx, "inc"); err != nil || target != "@sdk/include" {
+		t.Fatalf("unexpected target: expected %v, got %v (%v)", "@sdk/include", target, err)
+	}
+	e, err := s.Lookup(ctx, nil, "@sdk/include/a.h")
+	if err != nil || e == nil {
+		t.Fatalf("file lookup error: %v", err)
+	}
+	if a, err := s.Lookup(ctx, nil, "inc/a.h"); err != nil || a != e {
+		t.Fatalf("unexpected node: expected %v, got %v (%v)", e, a, err)
+	}
+}
+
+func TestNewImporter_RelativeRoot(t *testing.T) {
+	for _, root := range []string{".", "src"} {
+		t.Run(root, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			dir, err := ioutil.TempDir("", "mobius")
+			if err != nil {
+				t.Skipf("error creating temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Skipf("error getting working dir: %v", err)
+			}
+			if err := os.Chdir(dir); err != nil {
+				t.Skipf("error changing working dir: %v", err)
+			}
+			defer os.Chdir(wd)
+			io := afero.NewOsFs()
+			io.MkdirAll(filepath.Join(root, "a"), 0755)
+			if err := afero.WriteFile(io, filepath.Join(root, "a/b.c"), []byte{}, 0644); err != nil {
+				t.Skipf("error writing file: %v", err)
+			}
+			s := newFs(ctx, t)
+			// Test
+			if err := NewImporter(io, root).Import(ctx, s); err != nil {
+				t.Fatalf("error importing: %v", err)
+			}
+			node, err := s.Lookup(ctx, nil, "a/b.c")
+			if err != nil || node == nil {
+				t.Fatalf("file lookup error: %v", err)
+			}
+		})
+	}
+}
@@ -7,17 +7,42 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"github.com/cayleygraph/cayley/graph"
+	"time"
+	"sort"
+	"strings"
+	"regexp"
+	"github.com/cayleygraph/cayley/graph/shape"
+	"sync"
 )
 
 const (
 	Basename = quad.IRI("fs:name")
 	Dir      = quad.IRI("fs:dir")
 	Root     = quad.IRI("fs:root")
+	Kind     = quad.IRI("fs:kind")
+	Size     = quad.IRI("fs:size")
+	ModTime  = quad.IRI("fs:mtime")
+	Hash     = quad.IRI("fs:hash")
+	RootName = quad.IRI("fs:rootname")
+	Link     = quad.IRI("fs:link")
+	Target   = quad.IRI("fs:target")
+	Resolve  = quad.IRI("fs:resolve")
+)
+
+const (
+	KindUnknown = iota
+	KindFile
+	KindDir
+	KindSymlink
 )
 
 var (
 	RootMorphism = path.StartMorphism().Has(quad.IRI("rdf:type"), quad.IRI(Root))
 	UpMorphism   = path.StartMorphism().Out(Dir)
+	// ResolveMorphism follows symlinks to their final target. Other nodes
+	// resolve to themselves.
+	ResolveMorphism = path.StartMorphism().Out(Resolve)
 )
 
 func DownMorphism(basenames ...string) *path.Path {
@@ -28,21 +53,84 @@ func DownMorphism(basenames ...string) *path.Path {
 	return path.StartMorphism().In(Dir).Has(Basename, values...)
 }
 
+// DownFoldMorphism is DownMorphism, except that basename is matched ignoring
+// case.
+func DownFoldMorphism(basename string) *path.Path {
+	re := regexp.MustCompile("(?i)^" + regexp.QuoteMeta(basename) + "$")
+	return path.StartMorphism().In(Dir).HasFilter(Basename, false, shape.Regexp{Re: re})
+}
+
+// Meta is the metadata of a file as last imported. Hash is a hex SHA-256
+// digest of the content of regular files and Link is the target of symlinks.
+type Meta struct {
+	Kind    int       `quad:"fs:kind,opt"`
+	Size    int64     `quad:"fs:size,opt"`
+	ModTime time.Time `quad:"fs:mtime,opt"`
+	Hash    string    `quad:"fs:hash,opt"`
+	Link    string    `quad:"fs:link,opt"`
+}
+
 type File struct {
 	IRI  quad.IRI `quad:"@id"`
 	Name string   `quad:"fs:name"`
 	Dir  *File    `quad:"fs:dir,opt"`
+	Meta
+}
+
+// RootPrefix starts a path relative to a named root rather than the root of
+// the Fs, e.g. @sdk/include. The default root is named by an empty name. If
+// no root has the name the path is taken literally, and Literal escapes a
+// path so that it always is.
+const RootPrefix = "@"
+
+// Literal returns path such that a first name starting with RootPrefix does
+// not name a root.
+func Literal(path string) string {
+	if strings.HasPrefix(path, RootPrefix) {
+		return "./" + path
+	}
+	return path
+}
+
+// splitRoot splits a path starting with RootPrefix into the root name and the
+// path below the root.
+func splitRoot(path string) (name, rel string, ok bool) {
+	if !strings.HasPrefix(path, RootPrefix) {
+		return "", path, false
+	}
+	name, rel = strings.TrimPrefix(filepath.ToSlash(path), RootPrefix), "."
+	if i := strings.Index(name, "/"); i >= 0 {
+		name, rel = name[:i], name[i+1:]
+	}
+	return name, rel, true
+}
+
+// parent returns the directory of path, keeping a literal path literal.
+func parent(path string) string {
+	up := Path(path).Up().String()
+	if !strings.HasPrefix(path, RootPrefix) {
+		up = Literal(up)
+	}
+	return up
 }
 
 type root struct {
-	rdfType struct{} `quad:"@type > fs:root"`
+	rdfType  struct{} `quad:"@type > fs:root"`
 	File
+	RootName string   `quad:"fs:rootname,opt"`
 }
 
 func NewRoot(store *store.Store) (File, error) {
+	return NewNamedRoot(store, "")
+}
+
+// NewNamedRoot creates a root for another tree in the same store.
+func NewNamedRoot(store *store.Store, name string) (File, error) {
 	r := root{}
 	r.IRI = store.GenerateIRI(nil)
 	r.Name = "."
+	r.Kind = KindDir
+	r.RootName = name
 	_, err := store.Insert(r)
 	if err != nil {
 		return File{}, err
@@ -50,27 +138,63 @@ func NewRoot(store *store.Store) (File, error) {
 	return r.File, nil
 }
 
+// FindRoot finds the default root.
 func FindRoot(ctx context.Context, store *store.Store, dst interface{}) (quad.Value, error) {
-	v, err := path.StartPath(store.Graph).Follow(RootMorphism).Iterate(ctx).FirstValue(nil)
-	if err != nil || v == nil {
+	return FindNamedRoot(ctx, store, "", dst)
+}
+
+// FindNamedRoot finds the root with name.
+func FindNamedRoot(ctx context.Context, store *store.Store, name string, dst interface{}) (quad.Value, error) {
+	p := path.StartPath(store.Graph).Follow(RootMorphism)
+	if name == "" {
+		p = p.Except(p.Has(RootName))
+	} else {
+		p = p.Has(RootName, quad.String(name))
+	}
+	v, err := p.Iterate(ctx).FirstValue(nil)
+	if err != nil || v == nil || dst == nil {
 		return v, err
 	}
 	return v, store.Select(ctx, dst, v)
 }
 
+// RootNames returns the names of the named roots, sorted.
+func RootNames(ctx context.Context, store *store.Store) ([]string, error) {
+	values, err := path.StartPath(store.Graph).Follow(RootMorphism).Out(RootName).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		if name, ok := v.Native().(string); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 type Fs struct {
 	Store *store.Store
 	Root  File
+	// FoldCase makes lookups that find no exact match retry ignoring case,
+	// as on case-insensitive filesystems.
+	FoldCase bool
 }
 
 func NewFs(ctx context.Context, store *store.Store) (*Fs, error) {
+	return NewNamedFs(ctx, store, "")
+}
+
+// NewNamedFs returns a Fs bound to the root with name, creating it if needed.
+func NewNamedFs(ctx context.Context, store *store.Store, name string) (*Fs, error) {
 	var root File
-	v, err := FindRoot(ctx, store, &root)
+	v, err := FindNamedRoot(ctx, store, name, &root)
 	if err != nil {
 		return nil, fmt.Errorf("error finding fs root: %v", err)
 	}
 	if v == nil {
-		root, err = NewRoot(store)
+		root, err = NewNamedRoot(store, name)
 		if err != nil {
 			return nil, fmt.Errorf("error creating fs root: %v", err)
 		}
@@ -81,8 +205,33 @@ func NewFs(ctx context.Context, store *store.Store) (*Fs, error) {
 	}, nil
 }
 
-func (fs *Fs) Lookup(ctx context.Context, dst interface{}, path string) (node quad.Value, err error) {
-	node, err = Path(path).StartPath(fs.Store.Graph, fs.Root.IRI).Iterate(ctx).FirstValue(nil)
+// Lookup finds the node at path, resolving symlinks. Paths starting with
+// RootPrefix are looked up from the named root, if there is one.
+func (fs *Fs) Lookup(ctx context.Context, dst interface{}, path string) (quad.Value, error) {
+	return fs.lookup(ctx, dst, path, true)
+}
+
+// LookupLink is Lookup, except that a symlink at path is not resolved.
+func (fs *Fs) LookupLink(ctx context.Context, dst interface{}, path string) (quad.Value, error) {
+	return fs.lookup(ctx, dst, path, false)
+}
+
+func (fs *Fs) lookup(ctx context.Context, dst interface{}, path string, follow bool) (node quad.Value, err error) {
+	start := quad.Value(fs.Root.IRI)
+	if name, rel, ok := splitRoot(path); ok {
+		var root quad.Value
+		if root, err = FindNamedRoot(ctx, fs.Store, name, nil); err != nil {
+			return
+		}
+		// Otherwise a file of the name
+		if root != nil {
+			start, path = root, rel
+		}
+	}
+	node, err = Path(path).start(fs.Store.Graph, follow, false, start).Iterate(ctx).FirstValue(nil)
+	if err == nil && node == nil && fs.FoldCase {
+		node, err = Path(path).start(fs.Store.Graph, follow, true, start).Iterate(ctx).FirstValue(nil)
+	}
 	if err != nil {
 		return
 	}
@@ -107,8 +256,12 @@ func (fs *Fs) Open(ctx context.Context, path string) (f File, err error) {
 	return
 }
 
-func (fs *Fs) Create(ctx context.Context, path string) (f File, err error) {
-	node, err := fs.Lookup(ctx, &f, path)
+func (fs *Fs) Create(ctx context.Context, path string) (File, error) {
+	return fs.create(ctx, path, Meta{})
+}
+
+func (fs *Fs) create(ctx context.Context, path string, m Meta) (f File, err error) {
+	node, err := fs.LookupLink(ctx, &f, path)
 	if err != nil {
 		return
 	}
@@ -116,15 +269,18 @@ func (fs *Fs) Create(ctx context.Context, path string) (f File, err error) {
 		err = fmt.Errorf("file %v exists", path)
 		return
 	}
+	if err = fs.checkRoot(ctx, path); err != nil {
+		return
+	}
 	p := Path(path)
-	up := p.Up().String()
+	up := parent(path)
 	var dir File
 	node, err = fs.Lookup(ctx, &dir, up)
 	if err != nil {
 		return
 	}
 	if node == nil {
-		dir, err = fs.Create(ctx, up)
+		dir, err = fs.mkdir(ctx, up)
 		if err != nil {
 			return
 		}
@@ -132,31 +288,446 @@ func (fs *Fs) Create(ctx context.Context, path string) (f File, err error) {
 	f.IRI = fs.Store.GenerateIRI(nil)
 	f.Name = p.Base()
 	f.Dir = &dir
-	_, err = fs.Store.Insert(f)
+	f.Meta = m
+	if _, err = fs.Store.Insert(f); err != nil {
+		return
+	}
+	if m.Kind != KindSymlink {
+		err = fs.Store.Graph.AddQuad(quad.Make(f.IRI, Resolve, f.IRI, nil))
+	}
 	return
 }
 
-func (fs *Fs) Path(ctx context.Context, node quad.Value) (s string, err error) {
-	p := path.StartPath(fs.Store.Graph).FollowRecursive(UpMorphism, -1, nil)
-	var nodes []quad.Value
-	nodes, err = p.Iterate(ctx).AllValues(nil)
+// checkRoot returns an error if path starts with the name of a root that does
+// not exist, unless a file of the name does. A file of a new name starting
+// with RootPrefix is created by its Literal path.
+func (fs *Fs) checkRoot(ctx context.Context, path string) error {
+	name, _, ok := splitRoot(path)
+	if !ok {
+		return nil
+	}
+	root, err := FindNamedRoot(ctx, fs.Store, name, nil)
+	if err != nil || root != nil {
+		return err
+	}
+	top := RootPrefix + name
+	node, err := fs.LookupLink(ctx, nil, Literal(top))
+	if err != nil || node != nil {
+		return err
+	}
+	return fmt.Errorf("root %v not found (a file named %v is created as %v)", name, top, Literal(top))
+}
+
+// mkdir creates the directory at path for a file created below it.
+func (fs *Fs) mkdir(ctx context.Context, path string) (File, error) {
+	return fs.create(ctx, path, Meta{Kind: KindDir})
+}
+
+// Path returns the path of node relative to the root.
+func (fs *Fs) Path(ctx context.Context, node quad.Value) (string, error) {
+	paths, err := fs.PathsOf(ctx, node)
 	if err != nil {
-		err = fmt.Errorf("error finding nodes up: %v", err)
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// pathCache caches the paths of nodes by store and root, so that every Fs of
+// a store shares them. Changes to the tree through any Fs clear the store's
+// paths.
+var pathCache = struct {
+	sync.Mutex
+	paths map[*store.Store]map[quad.Value]map[quad.Value]string
+}{paths: make(map[*store.Store]map[quad.Value]map[quad.Value]string)}
+
+// clearPaths clears the cached paths of the store.
+func (fs *Fs) clearPaths() {
+	pathCache.Lock()
+	delete(pathCache.paths, fs.Store)
+	pathCache.Unlock()
+}
+
+// PathsOf returns the paths of nodes relative to the root. Paths are cached,
+// so directories shared by nodes are only read once.
+func (fs *Fs) PathsOf(ctx context.Context, nodes ...quad.Value) ([]string, error) {
+	pathCache.Lock()
+	defer pathCache.Unlock()
+	roots, ok := pathCache.paths[fs.Store]
+	if !ok {
+		roots = make(map[quad.Value]map[quad.Value]string)
+		pathCache.paths[fs.Store] = roots
+	}
+	cache, ok := roots[fs.Root.IRI]
+	if !ok {
+		cache = map[quad.Value]string{fs.Root.IRI: "."}
+		roots[fs.Root.IRI] = cache
+	}
+	paths := make([]string, len(nodes))
+	for n, node := range nodes {
+		// Walk up to the root or a node with a known path
+		var (
+			up    []quad.Value
+			names []string
+		)
+		seen := make(map[quad.Value]struct{})
+		p, ok := cache[node]
+		for !ok {
+			if _, ok := seen[node]; ok {
+				return nil, fmt.Errorf("cycle at node %v", node)
+			}
+			seen[node] = struct{}{}
+			name, dir, err := fs.up(ctx, node)
+			if err != nil {
+				return nil, fmt.Errorf("error reading node %v: %v", node, err)
+			}
+			if dir == nil {
+				// Root of another tree
+				var r root
+				if err := fs.Store.Select(ctx, &r, node); err != nil {
+					return nil, fmt.Errorf("root not found from node %v: %v", nodes[n], err)
+				}
+				p, ok = RootPrefix+r.RootName, true
+				cache[node] = p
+				break
+			}
+			up = append(up, node)
+			names = append(names, name)
+			node = dir
+			p, ok = cache[node]
+		}
+		// Walk back down caching paths
+		for i := len(up) - 1; i >= 0; i-- {
+			p = filepath.Join(p, names[i])
+			cache[up[i]] = p
+		}
+		paths[n] = p
+	}
+	return paths, nil
+}
+
+// up returns the name and directory of node.
+func (fs *Fs) up(ctx context.Context, node quad.Value) (name string, dir quad.Value, err error) {
+	qs := fs.Store.Graph.QuadStore
+	v := qs.ValueOf(node)
+	if v == nil {
 		return
 	}
-	nodes = append([]quad.Value{node}, nodes...)
-	for _, node = range nodes {
-		var file File
-		err = fs.Store.Select(ctx, &file, node)
+	it := qs.QuadIterator(quad.Subject, v)
+	defer it.Close()
+	for it.Next(ctx) {
+		q := qs.Quad(it.Result())
+		switch q.Predicate {
+		case Basename:
+			name, _ = q.Object.Native().(string)
+		case Dir:
+			dir = q.Object
+		}
+	}
+	err = it.Err()
+	return
+}
+
+// owned are the predicates linking files to nodes that belong to them.
+var owned []interface{}
+
+// RegisterOwned registers pred as linking files to nodes that belong to them,
+// such as the include directives scanned from a file, so that Remove removes
+// those nodes with the file.
+func RegisterOwned(pred quad.IRI) {
+	owned = append(owned, pred)
+}
+
+// Remove removes the file at name, the nodes it owns and all quads
+// referencing them. Directories with children are only removed if recursive
+// is set.
+func (fs *Fs) Remove(ctx context.Context, name string, recursive bool) error {
+	node, err := fs.LookupLink(ctx, nil, name)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("file %v not found", name)
+	}
+	_, dir, err := fs.up(ctx, node)
+	if err != nil {
+		return err
+	}
+	if dir == nil {
+		return fmt.Errorf("cannot remove root")
+	}
+	down := path.StartMorphism().In(Dir)
+	children, err := path.StartPath(fs.Store.Graph, node).FollowRecursive(down, -1, nil).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		return fmt.Errorf("error finding nodes down: %v", err)
+	}
+	if len(children) > 0 && !recursive {
+		return fmt.Errorf("directory %v not empty", name)
+	}
+	nodes := append(children, node)
+	if len(owned) > 0 {
+		owns, err := path.StartPath(fs.Store.Graph, nodes...).Out(owned...).Iterate(ctx).AllValues(nil)
 		if err != nil {
-			err = fmt.Errorf("error reading node %v: %v", node, err)
-			return
+			return fmt.Errorf("error finding owned nodes: %v", err)
 		}
-		if file.IRI == fs.Root.IRI {
-			return
+		nodes = append(nodes, owns...)
+	}
+	fs.clearPaths()
+	return fs.Store.RemoveNodes(ctx, nodes...)
+}
+
+// Rename moves the file at oldpath to newpath, creating missing directories.
+// The node keeps its IRI so edges attached to it are preserved. Symlinks are
+// not updated until ResolveLinks.
+func (fs *Fs) Rename(ctx context.Context, oldpath, newpath string) error {
+	var f File
+	node, err := fs.LookupLink(ctx, &f, oldpath)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("file %v not found", oldpath)
+	}
+	if f.Dir == nil {
+		return fmt.Errorf("cannot rename root")
+	}
+	exists, err := fs.LookupLink(ctx, nil, newpath)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return fmt.Errorf("file %v exists", newpath)
+	}
+	if err := fs.checkRoot(ctx, newpath); err != nil {
+		return err
+	}
+	p := Path(newpath)
+	// Validate against the deepest directory that exists, so that nothing is
+	// created for a rename that fails
+	var dir File
+	missing := false
+	for up := parent(newpath); ; up = parent(up) {
+		dirNode, err := fs.Lookup(ctx, &dir, up)
+		if err != nil {
+			return err
+		}
+		if dirNode != nil {
+			break
+		}
+		if up == parent(up) {
+			return fmt.Errorf("directory %v not found", up)
 		}
-		s = filepath.Join(file.Name, s)
+		missing = true
 	}
-	err = fmt.Errorf("root not found")
-	return
-}
\ No newline at end of file
+	ups, err := path.StartPath(fs.Store.Graph, dir.IRI).FollowRecursive(UpMorphism, -1, nil).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		return fmt.Errorf("error finding nodes up: %v", err)
+	}
+	for _, v := range append(ups, dir.IRI) {
+		if v == node {
+			return fmt.Errorf("cannot move %v into itself", oldpath)
+		}
+	}
+	if missing {
+		if dir, err = fs.mkdir(ctx, parent(newpath)); err != nil {
+			return err
+		}
+	}
+	tx := graph.NewTransaction()
+	tx.RemoveQuad(quad.Make(f.IRI, Basename, quad.String(f.Name), nil))
+	tx.RemoveQuad(quad.Make(f.IRI, Dir, f.Dir.IRI, nil))
+	tx.AddQuad(quad.Make(f.IRI, Basename, quad.String(p.Base()), nil))
+	tx.AddQuad(quad.Make(f.IRI, Dir, dir.IRI, nil))
+	// Paths below the file change
+	fs.clearPaths()
+	return fs.Store.Graph.ApplyTransaction(tx)
+}
+
+// SetMeta replaces the metadata of node. A node changed to or from a symlink
+// is left unresolved until ResolveLinks.
+func (fs *Fs) SetMeta(ctx context.Context, node quad.Value, m Meta) error {
+	qs := fs.Store.Graph.QuadStore
+	tx := graph.NewTransaction()
+	resolved := false
+	if v := qs.ValueOf(node); v != nil {
+		it := qs.QuadIterator(quad.Subject, v)
+		for it.Next(ctx) {
+			q := qs.Quad(it.Result())
+			switch q.Predicate {
+			case Kind, Size, ModTime, Hash, Link:
+				tx.RemoveQuad(q)
+			case Target:
+				if m.Kind != KindSymlink {
+					tx.RemoveQuad(q)
+				}
+			case Resolve:
+				if (m.Kind == KindSymlink) == (q.Object == node) {
+					tx.RemoveQuad(q)
+				} else {
+					resolved = q.Object == node
+				}
+			}
+		}
+		err := it.Err()
+		it.Close()
+		if err != nil {
+			return err
+		}
+	}
+	for _, q := range []struct {
+		pred quad.IRI
+		val  quad.Value
+		set  bool
+	}{
+		{Kind, quad.Int(m.Kind), m.Kind != KindUnknown},
+		{Size, quad.Int(m.Size), m.Size != 0},
+		{ModTime, quad.Time(m.ModTime), !m.ModTime.IsZero()},
+		{Hash, quad.String(m.Hash), m.Hash != ""},
+		{Link, quad.String(m.Link), m.Link != ""},
+		{Resolve, node, m.Kind != KindSymlink && !resolved},
+	} {
+		if q.set {
+			tx.AddQuad(quad.Make(node, q.pred, q.val, nil))
+		}
+	}
+	return fs.Store.Graph.ApplyTransaction(tx)
+}
+
+// ReadDir returns the files in the directory at name, sorted by name.
+func (fs *Fs) ReadDir(ctx context.Context, name string) ([]File, error) {
+	node, err := fs.Lookup(ctx, nil, name)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, fmt.Errorf("file %v not found", name)
+	}
+	return fs.readDir(ctx, node)
+}
+
+func (fs *Fs) readDir(ctx context.Context, node quad.Value) ([]File, error) {
+	nodes, err := path.StartPath(fs.Store.Graph, node).Follow(DownMorphism()).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error finding nodes down: %v", err)
+	}
+	var files []File
+	if len(nodes) == 0 {
+		return files, nil
+	}
+	if err := fs.Store.Select(ctx, &files, nodes...); err != nil {
+		return nil, fmt.Errorf("error reading nodes down: %v", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// WalkFunc is called by Walk for each file. If reading a directory fails fn
+// is called again for it with the error. Returning filepath.SkipDir from a
+// directory skips its contents.
+type WalkFunc func(path string, f File, err error) error
+
+// Walk walks the tree at root depth-first in lexical order, calling fn for
+// each file including root.
+func (fs *Fs) Walk(ctx context.Context, root string, fn WalkFunc) error {
+	f, err := fs.Open(ctx, root)
+	if err != nil {
+		err = fn(root, f, err)
+	} else {
+		err = fs.walk(ctx, root, f, fn)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func (fs *Fs) walk(ctx context.Context, p string, f File, fn WalkFunc) error {
+	if err := fn(p, f, nil); err != nil {
+		return err
+	}
+	files, err := fs.readDir(ctx, f.IRI)
+	if err != nil {
+		return fn(p, f, err)
+	}
+	for _, c := range files {
+		if err := fs.walk(ctx, filepath.Join(p, c.Name), c, fn); err != nil && err != filepath.SkipDir {
+			return err
+		}
+	}
+	return nil
+}
+
+// Glob returns the paths of the files matching pattern, sorted. Pattern
+// elements are matched as by filepath.Match, and a ** element matches any
+// number of directories, not including symlinks.
+func (fs *Fs) Glob(ctx context.Context, pattern string) ([]string, error) {
+	var elems []string
+	for _, e := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if e != "" && e != "." {
+			elems = append(elems, e)
+		}
+	}
+	for _, e := range elems {
+		if _, err := filepath.Match(e, ""); err != nil {
+			return nil, err
+		}
+	}
+	found := make(map[string]struct{})
+	if err := fs.glob(ctx, ".", fs.Root.IRI, elems, found); err != nil {
+		return nil, err
+	}
+	matches := make([]string, 0, len(found))
+	for p := range found {
+		matches = append(matches, p)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (fs *Fs) glob(ctx context.Context, p string, node quad.Value, elems []string, found map[string]struct{}) error {
+	if len(elems) == 0 {
+		found[p] = struct{}{}
+		return nil
+	}
+	// Match below the target of a symlink
+	node, err := fs.resolveNode(ctx, node)
+	if err != nil {
+		return err
+	}
+	e := elems[0]
+	if e == "**" {
+		// Match no directories
+		if err := fs.glob(ctx, p, node, elems[1:], found); err != nil {
+			return err
+		}
+	} else if !strings.ContainsAny(e, "*?[\\") {
+		// Literal name
+		node, err := path.StartPath(fs.Store.Graph, node).Follow(DownMorphism(e)).Iterate(ctx).FirstValue(nil)
+		if err != nil || node == nil {
+			return err
+		}
+		return fs.glob(ctx, filepath.Join(p, e), node, elems[1:], found)
+	}
+	files, err := fs.readDir(ctx, node)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		next := elems[1:]
+		if e == "**" {
+			// Match one more directory, not following symlinks so as not to
+			// loop
+			if f.Kind == KindSymlink {
+				continue
+			}
+			next = elems
+		} else if ok, _ := filepath.Match(e, f.Name); !ok {
+			continue
+		}
+		if err := fs.glob(ctx, filepath.Join(p, f.Name), f.IRI, next, found); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,6 +5,11 @@ import (
 	"github.com/phyrwork/mobius/store"
 	"github.com/cayleygraph/cayley"
 	"context"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/graph/path"
+	"time"
+	"reflect"
+	"path/filepath"
 )
 
 func newStore(t *testing.T) *store.Store {
@@ -116,3 +121,774 @@ func TestFs_Create_Empty(t *testing.T) {
 		})
 	}
 }
+
+func TestFs_Remove(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		path      string
+		recursive bool
+		err       bool
+		removed   []string
+		kept      []string
+	}{
+		{
+			"file",
+			[]string{"a/b", "a/c"},
+			"a/b",
+			false,
+			false,
+			[]string{"a/b"},
+			[]string{"a", "a/c"},
+		},
+		{
+			"not empty",
+			[]string{"a/b"},
+			"a",
+			false,
+			true,
+			nil,
+			[]string{"a", "a/b"},
+		},
+		{
+			"recursive",
+			[]string{"a/b/c", "a/d", "e"},
+			"a",
+			true,
+			false,
+			[]string{"a", "a/b", "a/b/c", "a/d"},
+			[]string{"e"},
+		},
+		{
+			"not found",
+			[]string{"a"},
+			"b",
+			false,
+			true,
+			nil,
+			[]string{"a"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			for _, path := range test.files {
+				if _, err := fs.Create(ctx, path); err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+			}
+			// Test
+			err := fs.Remove(ctx, test.path, test.recursive)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: expected %v, got %v", test.err, err)
+			}
+			for _, path := range test.removed {
+				node, err := fs.Lookup(ctx, nil, path)
+				if err != nil {
+					t.Fatalf("file lookup error: %v", err)
+				}
+				if node != nil {
+					t.Fatalf("removed file %v found", path)
+				}
+			}
+			for _, path := range test.kept {
+				if _, err := fs.Open(ctx, path); err != nil {
+					t.Fatalf("error opening file: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestFs_Remove_Owned(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	fs := newFs(t)
+	RegisterOwned(quad.IRI("test:owned"))
+	f, err := fs.Create(ctx, "a/b")
+	if err != nil {
+		t.Skipf("error creating file: %v", err)
+	}
+	owned := quad.IRI("test:node")
+	for _, q := range []quad.Quad{
+		quad.Make(f.IRI, quad.IRI("test:owned"), owned, nil),
+		quad.Make(owned, quad.IRI("test:text"), quad.String("text"), nil),
+	} {
+		if err := fs.Store.Graph.AddQuad(q); err != nil {
+			t.Skipf("error adding quad: %v", err)
+		}
+	}
+	// Test
+	if err := fs.Remove(ctx, "a", true); err != nil {
+		t.Fatalf("error removing file: %v", err)
+	}
+	v, err := path.StartPath(fs.Store.Graph, owned).Out(quad.IRI("test:text")).Iterate(ctx).FirstValue(nil)
+	if err != nil || v != nil {
+		t.Fatalf("owned node not removed: %v", err)
+	}
+}
+
+func TestFs_Rename(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		oldpath string
+		newpath string
+		err     bool
+		moved   map[string]string
+	}{
+		{
+			"rename",
+			[]string{"a/b"},
+			"a/b",
+			"a/c",
+			false,
+			map[string]string{"a/b": "a/c"},
+		},
+		{
+			"move",
+			[]string{"a/b", "c"},
+			"a/b",
+			"c/b",
+			false,
+			map[string]string{"a/b": "c/b"},
+		},
+		{
+			"directory",
+			[]string{"a/b/c"},
+			"a/b",
+			"d/e",
+			false,
+			map[string]string{"a/b": "d/e", "a/b/c": "d/e/c"},
+		},
+		{
+			"exists",
+			[]string{"a", "b"},
+			"a",
+			"b",
+			true,
+			nil,
+		},
+		{
+			"into itself",
+			[]string{"a/b"},
+			"a",
+			"a/b/c",
+			true,
+			nil,
+		},
+		{
+			"into itself, missing directories",
+			[]string{"a/x"},
+			"a",
+			"a/b/c",
+			true,
+			nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			for _, path := range test.files {
+				if _, err := fs.Create(ctx, path); err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+			}
+			files := make(map[string]File)
+			for old := range test.moved {
+				f, err := fs.Open(ctx, old)
+				if err != nil {
+					t.Skipf("error opening file: %v", err)
+				}
+				files[old] = f
+			}
+			// Edges to the moved file must survive
+			edge := quad.Make(fs.Root.IRI, quad.IRI("test:edge"), files[test.oldpath].IRI, nil)
+			if err := fs.Store.Graph.AddQuad(edge); err != nil {
+				t.Skipf("error adding edge: %v", err)
+			}
+			// Test
+			err := fs.Rename(ctx, test.oldpath, test.newpath)
+			if (err != nil) != test.err {
+				t.Fatalf("unexpected error: expected %v, got %v", test.err, err)
+			}
+			for old, new := range test.moved {
+				node, err := fs.Lookup(ctx, nil, old)
+				if err != nil {
+					t.Fatalf("file lookup error: %v", err)
+				}
+				if node != nil {
+					t.Fatalf("moved file %v found", old)
+				}
+				a, err := fs.Open(ctx, new)
+				if err != nil {
+					t.Fatalf("error opening file: %v", err)
+				}
+				if a.IRI != files[old].IRI {
+					t.Fatalf("unexpected file: expected %v, got %v", files[old].IRI, a.IRI)
+				}
+			}
+			if !test.err {
+				v, err := path.StartPath(fs.Store.Graph, fs.Root.IRI).Out(quad.IRI("test:edge")).Iterate(ctx).FirstValue(nil)
+				if err != nil || v != files[test.oldpath].IRI {
+					t.Fatalf("edge not preserved: %v", err)
+				}
+				return
+			}
+			// Nothing is created for a failed rename
+			setup := make(map[Path]struct{})
+			for _, p := range test.files {
+				for up := Path(p); up != "."; up = up.Up() {
+					setup[up] = struct{}{}
+				}
+			}
+			for up := Path(test.newpath).Up(); up != "."; up = up.Up() {
+				if _, ok := setup[up]; ok {
+					continue
+				}
+				if node, err := fs.Lookup(ctx, nil, up.String()); err != nil || node != nil {
+					t.Fatalf("unexpected directory %v created", up)
+				}
+			}
+		})
+	}
+}
+
+func TestFs_SetMeta(t *testing.T) {
+	tests := []struct {
+		name   string
+		before Meta
+		after  Meta
+	}{
+		{
+			"set",
+			Meta{},
+			Meta{Kind: KindFile, Size: 3, ModTime: time.Unix(1500000000, 0).UTC(), Hash: "abc"},
+		},
+		{
+			"replace",
+			Meta{Kind: KindFile, Size: 3, ModTime: time.Unix(1500000000, 0).UTC(), Hash: "abc"},
+			Meta{Kind: KindFile, Size: 4, ModTime: time.Unix(1600000000, 0).UTC(), Hash: "abcd"},
+		},
+		{
+			"clear",
+			Meta{Kind: KindFile, Size: 3, ModTime: time.Unix(1500000000, 0).UTC(), Hash: "abc"},
+			Meta{Kind: KindFile},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			f, err := fs.Create(ctx, "a")
+			if err != nil {
+				t.Skipf("error creating file: %v", err)
+			}
+			if err := fs.SetMeta(ctx, f.IRI, test.before); err != nil {
+				t.Skipf("error setting metadata: %v", err)
+			}
+			// Test
+			if err := fs.SetMeta(ctx, f.IRI, test.after); err != nil {
+				t.Fatalf("error setting metadata: %v", err)
+			}
+			a, err := fs.Open(ctx, "a")
+			if err != nil {
+				t.Fatalf("error opening file: %v", err)
+			}
+			e := test.after
+			if a.Kind != e.Kind || a.Size != e.Size || !a.ModTime.Equal(e.ModTime) || a.Hash != e.Hash {
+				t.Fatalf("unexpected metadata: expected %+v, got %+v", e, a.Meta)
+			}
+		})
+	}
+}
+
+func TestFs_ReadDir(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		path  string
+		names []string
+	}{
+		{
+			"root",
+			[]string{"b", "a/c", "c"},
+			".",
+			[]string{"a", "b", "c"},
+		},
+		{
+			"directory",
+			[]string{"a/c", "a/b/d", "e"},
+			"a",
+			[]string{"b", "c"},
+		},
+		{
+			"empty",
+			[]string{"a"},
+			"a",
+			nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			for _, path := range test.files {
+				if _, err := fs.Create(ctx, path); err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+			}
+			// Test
+			files, err := fs.ReadDir(ctx, test.path)
+			if err != nil {
+				t.Fatalf("error reading directory: %v", err)
+			}
+			var names []string
+			for _, f := range files {
+				names = append(names, f.Name)
+			}
+			if !reflect.DeepEqual(test.names, names) {
+				t.Fatalf("unexpected files: expected %v, got %v", test.names, names)
+			}
+		})
+	}
+}
+
+func TestFs_Walk(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		root  string
+		skip  string
+		paths []string
+	}{
+		{
+			"root",
+			[]string{"b", "a/c", "a/b/d"},
+			".",
+			"",
+			[]string{".", "a", "a/b", "a/b/d", "a/c", "b"},
+		},
+		{
+			"subtree",
+			[]string{"b", "a/c", "a/b/d"},
+			"a/b",
+			"",
+			[]string{"a/b", "a/b/d"},
+		},
+		{
+			"skip dir",
+			[]string{"b", "a/c", "a/b/d"},
+			".",
+			"a",
+			[]string{".", "a", "b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			for _, path := range test.files {
+				if _, err := fs.Create(ctx, path); err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+			}
+			// Test
+			var paths []string
+			err := fs.Walk(ctx, test.root, func(path string, f File, err error) error {
+				if err != nil {
+					return err
+				}
+				paths = append(paths, path)
+				if path == test.skip {
+					return filepath.SkipDir
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("error walking: %v", err)
+			}
+			if !reflect.DeepEqual(test.paths, paths) {
+				t.Fatalf("unexpected paths: expected %v, got %v", test.paths, paths)
+			}
+		})
+	}
+}
+
+func TestFs_Glob(t *testing.T) {
+	files := []string{"a/b.c", "a/b.h", "a/c/d.h", "a/c/e/f.h", "g.h"}
+	tests := []struct {
+		name    string
+		pattern string
+		matches []string
+	}{
+		{
+			"literal",
+			"a/b.c",
+			[]string{"a/b.c"},
+		},
+		{
+			"star",
+			"a/*.h",
+			[]string{"a/b.h"},
+		},
+		{
+			"question",
+			"a/b.?",
+			[]string{"a/b.c", "a/b.h"},
+		},
+		{
+			"double star",
+			"**/*.h",
+			[]string{"a/b.h", "a/c/d.h", "a/c/e/f.h", "g.h"},
+		},
+		{
+			"double star middle",
+			"a/**/f.h",
+			[]string{"a/c/e/f.h"},
+		},
+		{
+			"no match",
+			"b/*",
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			for _, path := range files {
+				if _, err := fs.Create(ctx, path); err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+			}
+			// Test
+			matches, err := fs.Glob(ctx, test.pattern)
+			if err != nil {
+				t.Fatalf("error matching: %v", err)
+			}
+			if !reflect.DeepEqual(test.matches, matches) {
+				t.Fatalf("unexpected matches: expected %v, got %v", test.matches, matches)
+			}
+		})
+	}
+}
+
+func TestFs_Path(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{
+			"one level",
+			[]string{"a", "b"},
+		},
+		{
+			"nested",
+			[]string{"a/b/c", "a/d", "e/f"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			var nodes []quad.Value
+			for _, path := range test.files {
+				f, err := fs.Create(ctx, path)
+				if err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+				nodes = append(nodes, f.IRI)
+			}
+			// Test
+			for n, node := range nodes {
+				a, err := fs.Path(ctx, node)
+				if err != nil {
+					t.Fatalf("error getting path: %v", err)
+				}
+				if a != test.files[n] {
+					t.Fatalf("unexpected path: expected %v, got %v", test.files[n], a)
+				}
+			}
+			// Cached paths
+			paths, err := fs.PathsOf(ctx, nodes...)
+			if err != nil {
+				t.Fatalf("error getting paths: %v", err)
+			}
+			if !reflect.DeepEqual(test.files, paths) {
+				t.Fatalf("unexpected paths: expected %v, got %v", test.files, paths)
+			}
+		})
+	}
+}
+
+func TestFs_PathsOf_Rename(t *testing.T) {
+	ctx := context.TODO()
+	fs := newFs(t)
+	f, err := fs.Create(ctx, "a/b/c")
+	if err != nil {
+		t.Skipf("error creating file: %v", err)
+	}
+	if _, err := fs.PathsOf(ctx, f.IRI); err != nil {
+		t.Skipf("error getting paths: %v", err)
+	}
+	if err := fs.Rename(ctx, "a", "d"); err != nil {
+		t.Skipf("error renaming file: %v", err)
+	}
+	a, err := fs.Path(ctx, f.IRI)
+	if err != nil {
+		t.Fatalf("error getting path: %v", err)
+	}
+	if a != "d/b/c" {
+		t.Fatalf("unexpected path: expected %v, got %v", "d/b/c", a)
+	}
+}
+
+func TestFs_PathsOf_RenameShared(t *testing.T) {
+	ctx := context.TODO()
+	fs := newFs(t)
+	f, err := fs.Create(ctx, "a/b/c")
+	if err != nil {
+		t.Skipf("error creating file: %v", err)
+	}
+	other, err := NewFs(ctx, fs.Store)
+	if err != nil {
+		t.Skipf("error creating fs: %v", err)
+	}
+	copied := *fs
+	if _, err := copied.PathsOf(ctx, f.IRI); err != nil {
+		t.Skipf("error getting paths: %v", err)
+	}
+	// Renamed through another Fs of the store
+	if err := other.Rename(ctx, "a", "d"); err != nil {
+		t.Skipf("error renaming file: %v", err)
+	}
+	a, err := fs.Path(ctx, f.IRI)
+	if err != nil {
+		t.Fatalf("error getting path: %v", err)
+	}
+	if a != "d/b/c" {
+		t.Fatalf("unexpected path: expected %v, got %v", "d/b/c", a)
+	}
+}
+
+func TestNewNamedFs(t *testing.T) {
+	ctx := context.TODO()
+	s := newStore(t)
+	// Named root created first must not be found as the default root
+	sdk, err := NewNamedFs(ctx, s, "sdk")
+	if err != nil {
+		t.Fatalf("error creating named fs: %v", err)
+	}
+	def, err := NewFs(ctx, s)
+	if err != nil {
+		t.Fatalf("error creating fs: %v", err)
+	}
+	if def.Root.IRI == sdk.Root.IRI {
+		t.Fatalf("default root bound to named root")
+	}
+	again, err := NewNamedFs(ctx, s, "sdk")
+	if err != nil {
+		t.Fatalf("error finding named fs: %v", err)
+	}
+	if again.Root.IRI != sdk.Root.IRI {
+		t.Fatalf("unexpected root: expected %v, got %v", sdk.Root.IRI, again.Root.IRI)
+	}
+	names, err := RootNames(ctx, s)
+	if err != nil {
+		t.Fatalf("error listing roots: %v", err)
+	}
+	if !reflect.DeepEqual([]string{"sdk"}, names) {
+		t.Fatalf("unexpected roots: expected %v, got %v", []string{"sdk"}, names)
+	}
+}
+
+func TestFs_Lookup_NamedRoot(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		path string
+		file string
+	}{
+		{
+			"named from default",
+			"",
+			"@sdk/a/b.h",
+			"sdk:a/b.h",
+		},
+		{
+			"default from named",
+			"sdk",
+			"@/c.h",
+			":c.h",
+		},
+		{
+			"named root",
+			"",
+			"@sdk",
+			"sdk:.",
+		},
+		{
+			"unknown root",
+			"",
+			"@foo/a/b.h",
+			"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			s := newStore(t)
+			files := map[string]quad.Value{}
+			for _, name := range []string{"", "sdk"} {
+				f, err := NewNamedFs(ctx, s, name)
+				if err != nil {
+					t.Skipf("error creating fs: %v", err)
+				}
+				files[name+":."] = f.Root.IRI
+				for _, path := range []string{"a/b.h", "c.h"} {
+					file, err := f.Create(ctx, path)
+					if err != nil {
+						t.Skipf("error creating file: %v", err)
+					}
+					files[name+":"+path] = file.IRI
+				}
+			}
+			f, err := NewNamedFs(ctx, s, test.from)
+			if err != nil {
+				t.Skipf("error creating fs: %v", err)
+			}
+			// Test
+			node, err := f.Lookup(ctx, nil, test.path)
+			if err != nil {
+				t.Fatalf("file lookup error: %v", err)
+			}
+			if e := files[test.file]; node != e {
+				t.Fatalf("unexpected node: expected %v, got %v", e, node)
+			}
+			if node == nil {
+				return
+			}
+			p, err := f.Path(ctx, node)
+			if err != nil {
+				t.Fatalf("error getting path: %v", err)
+			}
+			if p != test.path {
+				t.Fatalf("unexpected path: expected %v, got %v", test.path, p)
+			}
+		})
+	}
+}
+
+func TestFs_Create_RootPrefix(t *testing.T) {
+	// Setup
+	ctx := context.TODO()
+	fs := newFs(t)
+	// Test
+	if _, err := fs.Create(ctx, "@typo/a.h"); err == nil {
+		t.Fatalf("expected error creating file below unknown root")
+	}
+	if node, err := fs.Lookup(ctx, nil, Literal("@typo")); err != nil || node != nil {
+		t.Fatalf("unexpected file @typo: %v", err)
+	}
+	// Files named with the prefix
+	for _, path := range []string{Literal("@scope/a.h"), "@scope/b.h"} {
+		if _, err := fs.Create(ctx, path); err != nil {
+			t.Fatalf("error creating file %v: %v", path, err)
+		}
+	}
+	files, err := fs.ReadDir(ctx, "@scope")
+	if err != nil {
+		t.Fatalf("error reading directory: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("unexpected files: %v", files)
+	}
+}
+
+func TestFs_Lookup_FoldCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		fold  bool
+		files []string
+		path  string
+		file  string
+	}{
+		{
+			"exact",
+			false,
+			[]string{"a/Foo.h"},
+			"a/Foo.h",
+			"a/Foo.h",
+		},
+		{
+			"case mismatch",
+			false,
+			[]string{"a/foo.h"},
+			"A/Foo.h",
+			"",
+		},
+		{
+			"fold case mismatch",
+			true,
+			[]string{"a/foo.h"},
+			"A/Foo.h",
+			"a/foo.h",
+		},
+		{
+			"fold backslash",
+			true,
+			[]string{"a/foo.h"},
+			"a\\FOO.H",
+			"a/foo.h",
+		},
+		{
+			"fold prefers exact",
+			true,
+			[]string{"a/foo.h", "a/Foo.h"},
+			"a/Foo.h",
+			"a/Foo.h",
+		},
+		{
+			"fold regexp characters",
+			true,
+			[]string{"a/foo.h"},
+			"a/foo.*",
+			"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			files := map[string]quad.Value{}
+			for _, path := range test.files {
+				f, err := fs.Create(ctx, path)
+				if err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+				files[path] = f.IRI
+			}
+			fs.FoldCase = test.fold
+			// Test
+			node, err := fs.Lookup(ctx, nil, test.path)
+			if err != nil {
+				t.Fatalf("file lookup error: %v", err)
+			}
+			if e := files[test.file]; node != e {
+				t.Fatalf("unexpected node: expected %v, got %v", e, node)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"github.com/cayleygraph/cayley/quad"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/cayley/graph"
+	"path/filepath"
+	"strings"
+)
+
+// Symlink creates a symlink at path to target. Relative targets are relative
+// to the directory of the symlink and targets starting with RootPrefix are
+// relative to the named root, if there is one. The symlink is resolved by
+// ResolveLinks.
+func (fs *Fs) Symlink(ctx context.Context, path, target string) (File, error) {
+	return fs.create(ctx, path, Meta{Kind: KindSymlink, Link: target})
+}
+
+// Readlink returns the target of the symlink at path.
+func (fs *Fs) Readlink(ctx context.Context, path string) (string, error) {
+	var f File
+	node, err := fs.LookupLink(ctx, &f, path)
+	if err != nil {
+		return "", err
+	}
+	if node == nil {
+		return "", fmt.Errorf("file %v not found", path)
+	}
+	if f.Kind != KindSymlink {
+		return "", fmt.Errorf("file %v not a symlink", path)
+	}
+	return f.Link, nil
+}
+
+// resolveNode returns the final target of node if it is a symlink.
+func (fs *Fs) resolveNode(ctx context.Context, node quad.Value) (quad.Value, error) {
+	v, err := path.StartPath(fs.Store.Graph, node).Follow(ResolveMorphism).Iterate(ctx).FirstValue(nil)
+	if err != nil || v == nil {
+		return node, err
+	}
+	return v, nil
+}
+
+// ResolveLinks links every symlink in the store to its target and final
+// target, so that paths resolve through them. Symlinks with missing targets
+// are left unresolved, and symlink loops are returned as errors.
+func (fs *Fs) ResolveLinks(ctx context.Context) (errs []error) {
+	g := fs.Store.Graph
+	links, err := path.StartPath(g).Has(Kind, quad.Int(KindSymlink)).Iterate(ctx).AllValues(nil)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error finding symlinks: %v", err))
+		return
+	}
+	r := linkResolver{
+		fs:     fs,
+		ctx:    ctx,
+		links:  make(map[quad.Value]struct{}),
+		final:  make(map[quad.Value]quad.Value),
+		active: make(map[quad.Value]struct{}),
+	}
+	for _, node := range links {
+		r.links[node] = struct{}{}
+	}
+	tx := graph.NewTransaction()
+	qs := g.QuadStore
+	for _, node := range links {
+		target, err := r.target(node)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		final, err := r.resolve(node)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		it := qs.QuadIterator(quad.Subject, qs.ValueOf(node))
+		for it.Next(ctx) {
+			q := qs.Quad(it.Result())
+			if q.Predicate == Target || q.Predicate == Resolve {
+				tx.RemoveQuad(q)
+			}
+		}
+		err = it.Err()
+		it.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error reading symlink %v: %v", node, err))
+			continue
+		}
+		if target != nil {
+			tx.AddQuad(quad.Make(node, Target, target, nil))
+		}
+		if final != nil {
+			tx.AddQuad(quad.Make(node, Resolve, final, nil))
+		}
+	}
+	if err := g.ApplyTransaction(tx); err != nil {
+		errs = append(errs, fmt.Errorf("error writing symlinks: %v", err))
+	}
+	fs.clearPaths()
+	return
+}
+
+// LinkTargets returns the final target of each resolved symlink in the store,
+// e.g. to find those retargeted by ResolveLinks.
+func (fs *Fs) LinkTargets(ctx context.Context) (map[quad.Value]quad.Value, error) {
+	targets := make(map[quad.Value]quad.Value)
+	qs := fs.Store.Graph.QuadStore
+	err := path.StartPath(qs).Has(Kind, quad.Int(KindSymlink)).Tag("link").Out(Resolve).Tag("target").Iterate(ctx).TagValues(qs, func(m map[string]quad.Value) {
+		targets[m["link"]] = m["target"]
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading symlinks: %v", err)
+	}
+	return targets, nil
+}
+
+// linkResolver follows symlink targets through the directory tree, without
+// relying on resolve edges that may not have been written yet.
+type linkResolver struct {
+	fs     *Fs
+	ctx    context.Context
+	links  map[quad.Value]struct{}
+	final  map[quad.Value]quad.Value
+	active map[quad.Value]struct{}
+}
+
+// target returns the node named by the target of symlink node, or nil if it
+// does not exist.
+func (r linkResolver) target(node quad.Value) (quad.Value, error) {
+	var f File
+	if err := r.fs.Store.Select(r.ctx, &f, node); err != nil {
+		return nil, fmt.Errorf("error reading symlink %v: %v", node, err)
+	}
+	text := filepath.ToSlash(f.Link)
+	if f.Dir == nil || text == "" || filepath.IsAbs(text) {
+		return nil, nil
+	}
+	cur := quad.Value(f.Dir.IRI)
+	if name, rel, ok := splitRoot(text); ok {
+		root, err := FindNamedRoot(r.ctx, r.fs.Store, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		// Otherwise a file of the name
+		if root != nil {
+			cur, text = root, rel
+		}
+	}
+	bases := strings.Split(text, "/")
+	for n, base := range bases {
+		switch base {
+		case "", ".":
+			continue
+		case "..":
+			_, dir, err := r.fs.up(r.ctx, cur)
+			if err != nil {
+				return nil, err
+			}
+			if dir != nil {
+				cur = dir
+			}
+			continue
+		}
+		next, err := path.StartPath(r.fs.Store.Graph, cur).Follow(DownMorphism(base)).Iterate(r.ctx).FirstValue(nil)
+		if err != nil || next == nil {
+			return nil, err
+		}
+		if n < len(bases)-1 {
+			if next, err = r.resolve(next); err != nil || next == nil {
+				return nil, err
+			}
+		}
+		cur = next
+	}
+	return cur, nil
+}
+
+// resolve returns the final target of node, or nil if a target is missing.
+func (r linkResolver) resolve(node quad.Value) (quad.Value, error) {
+	if _, ok := r.links[node]; !ok {
+		return node, nil
+	}
+	if final, ok := r.final[node]; ok {
+		return final, nil
+	}
+	if _, ok := r.active[node]; ok {
+		p, _ := r.fs.Path(r.ctx, node)
+		r.final[node] = nil
+		return nil, fmt.Errorf("symlink loop at %v", p)
+	}
+	r.active[node] = struct{}{}
+	defer delete(r.active, node)
+	target, err := r.target(node)
+	var final quad.Value
+	if err == nil && target != nil {
+		final, err = r.resolve(target)
+	}
+	if _, ok := r.final[node]; !ok {
+		r.final[node] = final
+	}
+	return r.final[node], err
+}
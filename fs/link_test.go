@@ -0,0 +1,144 @@
+package fs
+
+import (
+	"testing"
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+)
+
+func TestFs_ResolveLinks(t *testing.T) {
+	files := []string{"sdk/include/a.h", "vendor/b.h"}
+	links := map[string]string{
+		"vendor/sdk": "../sdk",
+		"l1":         "l2",
+		"l2":         "sdk",
+		"l3":         "l1/include",
+		"x":          "y",
+		"y":          "x",
+		"d":          "missing",
+		"abs":        "/sdk",
+	}
+	tests := []struct {
+		name string
+		path string
+		file string
+	}{
+		{
+			"directory",
+			"vendor/sdk/include/a.h",
+			"sdk/include/a.h",
+		},
+		{
+			"final",
+			"vendor/sdk",
+			"sdk",
+		},
+		{
+			"chain",
+			"l1/include/a.h",
+			"sdk/include/a.h",
+		},
+		{
+			"link in target",
+			"l3/a.h",
+			"sdk/include/a.h",
+		},
+		{
+			"parent of target",
+			"vendor/sdk/../vendor/b.h",
+			"vendor/b.h",
+		},
+		{
+			"loop",
+			"x/a.h",
+			"",
+		},
+		{
+			"missing",
+			"d",
+			"",
+		},
+		{
+			"absolute",
+			"abs",
+			"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Setup
+			ctx := context.TODO()
+			fs := newFs(t)
+			nodes := make(map[string]quad.Value)
+			for _, path := range files {
+				f, err := fs.Create(ctx, path)
+				if err != nil {
+					t.Skipf("error creating file: %v", err)
+				}
+				nodes[path] = f.IRI
+			}
+			for path, target := range links {
+				if _, err := fs.Symlink(ctx, path, target); err != nil {
+					t.Skipf("error creating symlink: %v", err)
+				}
+			}
+			for _, path := range []string{"sdk"} {
+				node, err := fs.Lookup(ctx, nil, path)
+				if err != nil || node == nil {
+					t.Skipf("file lookup error: %v", err)
+				}
+				nodes[path] = node
+			}
+			// Test
+			if errs := fs.ResolveLinks(ctx); len(errs) != 1 {
+				t.Fatalf("unexpected errors: expected one loop, got %v", errs)
+			}
+			node, err := fs.Lookup(ctx, nil, test.path)
+			if err != nil {
+				t.Fatalf("file lookup error: %v", err)
+			}
+			if e := nodes[test.file]; node != e {
+				t.Fatalf("unexpected node: expected %v, got %v", e, node)
+			}
+		})
+	}
+}
+
+func TestFs_LookupLink(t *testing.T) {
+	ctx := context.TODO()
+	fs := newFs(t)
+	if _, err := fs.Create(ctx, "a/b"); err != nil {
+		t.Skipf("error creating file: %v", err)
+	}
+	l, err := fs.Symlink(ctx, "c", "a")
+	if err != nil {
+		t.Skipf("error creating symlink: %v", err)
+	}
+	if errs := fs.ResolveLinks(ctx); len(errs) > 0 {
+		t.Skipf("error resolving symlinks: %v", errs)
+	}
+	node, err := fs.LookupLink(ctx, nil, "c")
+	if err != nil {
+		t.Fatalf("file lookup error: %v", err)
+	}
+	if node != l.IRI {
+		t.Fatalf("unexpected node: expected %v, got %v", l.IRI, node)
+	}
+	target, err := fs.Readlink(ctx, "c")
+	if err != nil {
+		t.Fatalf("error reading symlink: %v", err)
+	}
+	if target != "a" {
+		t.Fatalf("unexpected target: expected %v, got %v", "a", target)
+	}
+	// Removing the symlink leaves the target
+	if err := fs.Remove(ctx, "c", false); err != nil {
+		t.Fatalf("error removing symlink: %v", err)
+	}
+	if _, err := fs.Open(ctx, "a/b"); err != nil {
+		t.Fatalf("error opening file: %v", err)
+	}
+	if _, err := fs.Readlink(ctx, "c"); err == nil {
+		t.Fatalf("removed symlink found")
+	}
+}
@@ -26,10 +26,33 @@ func (s Path) Base() string {
 	return filepath.Base(string(s))
 }
 
+// StartPath follows the path from nodes, resolving symlinks.
 func (s Path) StartPath(g graph.QuadStore, nodes ...quad.Value) *path.Path {
+	return s.start(g, true, false, nodes...)
+}
+
+// StartLinkPath follows the path from nodes, resolving symlinks except for the
+// last element.
+func (s Path) StartLinkPath(g graph.QuadStore, nodes ...quad.Value) *path.Path {
+	return s.start(g, false, false, nodes...)
+}
+
+// StartFoldPath is StartPath, except that names are matched ignoring case
+// and backslashes also separate names, as on Windows.
+func (s Path) StartFoldPath(g graph.QuadStore, nodes ...quad.Value) *path.Path {
+	return s.start(g, true, true, nodes...)
+}
+
+func (s Path) start(g graph.QuadStore, follow, fold bool, nodes ...quad.Value) *path.Path {
 	p := path.StartPath(g, nodes...)
+	text := filepath.ToSlash(string(s))
+	if fold {
+		text = strings.Replace(text, "\\", "/", -1)
+	}
 	// TODO: Consider filepath.Split instead
-	for _, base := range strings.Split(filepath.ToSlash(string(s)), "/") {
+	bases := strings.Split(text, "/")
+	last := len(bases) - 1
+	for n, base := range bases {
 		switch base {
 		case "":
 			// No-op
@@ -38,7 +61,14 @@ func (s Path) StartPath(g graph.QuadStore, nodes ...quad.Value) *path.Path {
 		case "..":
 			p = p.Follow(UpMorphism)
 		default:
-			p = p.Follow(DownMorphism(base))
+			if fold {
+				p = p.Follow(DownFoldMorphism(base))
+			} else {
+				p = p.Follow(DownMorphism(base))
+			}
+			if follow || n < last {
+				p = p.Follow(ResolveMorphism)
+			}
 		}
 	}
 	return p
@@ -46,4 +76,8 @@ func (s Path) StartPath(g graph.QuadStore, nodes ...quad.Value) *path.Path {
 
 func (s Path) StartMorphism(nodes ...quad.Value) *path.Path {
 	return s.StartPath(nil, nodes...)
-}
\ No newline at end of file
+}
+
+func (s Path) StartFoldMorphism(nodes ...quad.Value) *path.Path {
+	return s.StartFoldPath(nil, nodes...)
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley"
+	_ "github.com/cayleygraph/cayley/graph/kv/bolt"
+	_ "github.com/cayleygraph/cayley/graph/kv/leveldb"
+	"fmt"
+)
+
+const (
+	DefaultBackend = "bolt"
+)
+
+// Config locates a quadstore: the name of a registered cayley backend and,
+// for persistent backends, the path of its database.
+type Config struct {
+	Backend string
+	Path    string
+	Options graph.Options
+}
+
+func (c Config) backend() string {
+	if c.Backend == "" {
+		return DefaultBackend
+	}
+	return c.Backend
+}
+
+// Open opens an existing quadstore. The path of a non-persistent backend is ignored.
+func Open(c Config) (*Store, error) {
+	path := c.Path
+	if !graph.IsPersistent(c.backend()) {
+		path = ""
+	}
+	g, err := cayley.NewGraph(c.backend(), path, c.Options)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v store %v: %v", c.backend(), c.Path, err)
+	}
+	return New(g), nil
+}
+
+// Init creates a quadstore and opens it.
+func Init(c Config) (*Store, error) {
+	if graph.IsPersistent(c.backend()) {
+		if err := graph.InitQuadStore(c.backend(), c.Path, c.Options); err != nil {
+			return nil, fmt.Errorf("error creating %v store %v: %v", c.backend(), c.Path, err)
+		}
+	}
+	return Open(c)
+}
+
+func (s *Store) Close() error {
+	return s.Graph.Close()
+}
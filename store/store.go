@@ -41,6 +41,30 @@ func (s *Store) Insert(o interface{}) (quad.Value, error) {
 	return node, err
 }
 
+// RemoveNodes removes every quad referencing nodes in one transaction.
+func (s *Store) RemoveNodes(ctx context.Context, nodes ...quad.Value) error {
+	qs := s.Graph.QuadStore
+	tx := graph.NewTransaction()
+	for _, node := range nodes {
+		v := qs.ValueOf(node)
+		if v == nil {
+			continue
+		}
+		for _, d := range []quad.Direction{quad.Subject, quad.Object, quad.Label} {
+			it := qs.QuadIterator(d, v)
+			for it.Next(ctx) {
+				tx.RemoveQuad(qs.Quad(it.Result()))
+			}
+			err := it.Err()
+			it.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return s.Graph.ApplyTransaction(tx)
+}
+
 type Valuer interface {
 	Value() quad.Value
 }
\ No newline at end of file
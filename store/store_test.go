@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"context"
+	"github.com/cayleygraph/cayley/quad"
+)
+
+type testObject struct {
+	IRI  quad.IRI `quad:"@id"`
+	Name string   `quad:"test:name"`
+}
+
+func TestInit_Open(t *testing.T) {
+	tests := []struct {
+		name       string
+		backend    string
+		persistent bool
+	}{
+		{"bolt", "bolt", true},
+		{"leveldb", "leveldb", true},
+		{"memstore", "memstore", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.TODO()
+			dir, err := ioutil.TempDir("", "mobius")
+			if err != nil {
+				t.Skipf("error creating temporary directory: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			c := Config{
+				Backend: test.backend,
+				Path:    filepath.Join(dir, "db"),
+			}
+			s, err := Init(c)
+			if err != nil {
+				t.Fatalf("error creating store: %v", err)
+			}
+			e := testObject{IRI: s.GenerateIRI(nil), Name: "a"}
+			if _, err := s.Insert(e); err != nil {
+				t.Fatalf("error inserting: %v", err)
+			}
+			if err := s.Close(); err != nil {
+				t.Fatalf("error closing store: %v", err)
+			}
+			if !test.persistent {
+				return
+			}
+			if _, err := Init(c); err == nil {
+				t.Fatalf("expected error creating existing store")
+			}
+			s, err = Open(c)
+			if err != nil {
+				t.Fatalf("error opening store: %v", err)
+			}
+			defer s.Close()
+			var a testObject
+			if err := s.Select(ctx, &a, e.IRI); err != nil {
+				t.Fatalf("error selecting: %v", err)
+			}
+			if a != e {
+				t.Fatalf("unexpected object: expected %v, got %v", e, a)
+			}
+		})
+	}
+}